@@ -1,7 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 
@@ -9,15 +15,55 @@ import (
 	"github.com/trelltron/twitch-stats-agg-demo/services"
 )
 
+// ShutdownTimeout is how long in-flight requests get to finish on shutdown
+const ShutdownTimeout = 10 * time.Second
+
 func main() {
 	godotenv.Load()
 	services := services.BuildServices()
 	router := routes.BuildRouter(&services)
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go services.Poller.Run(ctx)
+	go services.Sampler.Run(ctx)
+	go services.Followers.Run(ctx)
+
+	// The recorder outlives the server, so snapshots from requests that finish
+	// during shutdown are still written
+	recorderCtx, stopRecorder := context.WithCancel(context.Background())
+	recorded := make(chan struct{})
+	go func() {
+		services.Recorder.Run(recorderCtx)
+		close(recorded)
+	}()
+
 	address, exists := os.LookupEnv("SERVER_ADDRESS")
 	if !exists {
 		address = "localhost:3000"
 	}
 
-	router.Run(address)
+	server := &http.Server{Addr: address, Handler: router}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			services.Log.Error("Server failed", "err", err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	services.Log.Info("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		services.Log.Warn("Server did not shut down cleanly", "err", err)
+	}
+
+	stopRecorder()
+	<-recorded
+	if err := services.Store.Close(); err != nil {
+		services.Log.Error("Failed to close snapshot store", "err", err)
+	}
 }
@@ -8,7 +8,43 @@ import (
 func BuildRouter(services *services.Services) *gin.Engine {
 	router := gin.Default()
 	router.GET("/streamer/:channelId/stats", func(c *gin.Context) {
-		RouteGetStreamerStats(c, services.Log, &services.Twitch)
+		RouteGetStreamerStats(c, services.Log, services.Twitch, services.Store)
+	})
+	router.GET("/streamer/:channelId/stats/timeseries", func(c *gin.Context) {
+		RouteGetStreamerTimeseries(c, services.Log, services.Twitch)
+	})
+	router.GET("/streamer/:channelId/stats/heatmap", func(c *gin.Context) {
+		RouteGetStreamerHeatmap(c, services.Log, services.Twitch)
+	})
+	router.GET("/streamer/:channelId/clips/stats", func(c *gin.Context) {
+		RouteGetStreamerClipStats(c, services.Log, services.Twitch)
+	})
+	router.GET("/streamer/:channelId/live", func(c *gin.Context) {
+		RouteGetStreamerLive(c, services.Log, services.Twitch, services.Store)
+	})
+	router.GET("/streamer/:channelId/broadcasts", func(c *gin.Context) {
+		RouteGetStreamerBroadcasts(c, services.Log, services.Twitch, services.Store)
+	})
+	router.GET("/streamer/:channelId/followers", func(c *gin.Context) {
+		RouteGetStreamerFollowers(c, services.Log, services.Twitch, services.Store)
+	})
+	router.GET("/streamer/:channelId/history", func(c *gin.Context) {
+		RouteGetStreamerHistory(c, services.Log, services.Twitch, services.Store)
+	})
+	router.GET("/compare", func(c *gin.Context) {
+		RouteGetCompare(c, services.Log, services.Twitch)
+	})
+	router.GET("/watchlist", func(c *gin.Context) {
+		RouteGetWatchlist(c, services.Log, services.Poller)
+	})
+	router.GET("/watchlist/:channelId", func(c *gin.Context) {
+		RouteGetWatchedChannel(c, services.Log, services.Poller)
+	})
+	router.PUT("/watchlist/:channelId", func(c *gin.Context) {
+		RoutePutWatchedChannel(c, services.Log, services.Poller)
+	})
+	router.DELETE("/watchlist/:channelId", func(c *gin.Context) {
+		RouteDeleteWatchedChannel(c, services.Log, services.Poller)
 	})
 	return router
 }
@@ -0,0 +1,178 @@
+package routes
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+// Box art is sized to match twitch's own category directory
+const (
+	BoxArtWidth  = 285
+	BoxArtHeight = 380
+)
+
+type IGames interface {
+	GetGames(context.Context, []string) ([]twitch.Game, error)
+}
+
+// CategoryStats covers the videos streamed under one game. Videos whose game
+// isn't known are grouped together with an empty gameId and gameName.
+type CategoryStats struct {
+	GameID     string  `json:"gameId"`
+	GameName   string  `json:"gameName"`
+	BoxArtURL  string  `json:"boxArtUrl,omitempty"`
+	Hours      float64 `json:"hours"`
+	VideoCount int     `json:"videoCount"`
+	TotalViews int     `json:"totalViews"`
+	MeanViews  int     `json:"meanViews"`
+}
+
+// parseFlag reads an optional true/false query parameter
+func parseFlag(c *gin.Context, name string, errors *[]string) bool {
+	switch c.Query(name) {
+	case "", "false":
+		return false
+	case "true":
+		return true
+	default:
+		*errors = append(*errors, fmt.Sprintf("Invalid %s parameter", name))
+		return false
+	}
+}
+
+// fetchCategories breaks the videos down by game, along with the game of each
+// video. Games are only known for broadcasts the viewer sampler saw, and a
+// failure to look up the games leaves the breakdown less complete rather than
+// failing it. If no video's game is known, which is what happens whenever the
+// channel isn't sampled, the breakdown is the single unknown group. Only a
+// failure to read the samples writes an error response.
+func fetchCategories(c *gin.Context, log slog.Logger, service IGames, store IViewerSamples, videos []twitch.Video) ([]CategoryStats, map[string]string, bool) {
+	// Every video fetched belongs to the same user, and a broadcast is only
+	// sampled after its video is created
+	userId := videos[0].UserID
+	from := videos[0].CreatedAt
+	for _, video := range videos {
+		if video.CreatedAt.Before(from) {
+			from = video.CreatedAt
+		}
+	}
+	samples, err := store.ViewerSamples(userId, from, time.Now().UTC())
+	if err != nil {
+		log.Error("Failed to read viewer samples", "userId", userId, "err", err)
+		respondError(c, http.StatusInternalServerError, CodeInternal, MessageUnknown)
+		return nil, nil, false
+	}
+
+	categories, names := videoCategories(videos, samples)
+
+	boxArt := map[string]string{}
+	if len(names) > 0 {
+		games, err := service.GetGames(requestContext(c), slices.Sorted(maps.Keys(names)))
+		if err != nil {
+			log.Warn("Failed to look up games, falling back to sampled names", "games", len(names), "err", err)
+		}
+		for _, game := range games {
+			names[game.ID] = game.Name
+			boxArt[game.ID] = game.BoxArt(BoxArtWidth, BoxArtHeight)
+		}
+	}
+
+	log.Debug("Categorised videos", "userId", userId, "samples", len(samples), "categorised", len(categories), "games", len(names))
+	return generateCategories(videos, categories, names, boxArt), categories, true
+}
+
+// videoCategories finds the game each video was streamed under from the
+// viewer samples of its broadcast, along with the sampled name of each game. A
+// broadcast can switch games, so it is put down to the game it was sampled
+// under most, or the first of them if tied. Videos without samples, including
+// highlights and uploads which have no broadcast, are left out.
+func videoCategories(videos []twitch.Video, samples []storage.ViewerSample) (categories map[string]string, names map[string]string) {
+	type tally struct {
+		games  []string
+		counts map[string]int
+	}
+	streams := map[string]*tally{}
+	for _, sample := range samples {
+		if sample.GameID == "" {
+			continue
+		}
+		t, ok := streams[sample.StreamID]
+		if !ok {
+			t = &tally{counts: map[string]int{}}
+			streams[sample.StreamID] = t
+		}
+		if t.counts[sample.GameID] == 0 {
+			t.games = append(t.games, sample.GameID)
+		}
+		t.counts[sample.GameID]++
+	}
+
+	categories, names = map[string]string{}, map[string]string{}
+	gameNames := map[string]string{}
+	for _, sample := range samples {
+		gameNames[sample.GameID] = sample.GameName
+	}
+	for _, video := range videos {
+		t, ok := streams[video.StreamID]
+		if video.StreamID == "" || !ok {
+			continue
+		}
+		game := t.games[0]
+		for _, candidate := range t.games[1:] {
+			if t.counts[candidate] > t.counts[game] {
+				game = candidate
+			}
+		}
+		categories[video.ID] = game
+		names[game] = gameNames[game]
+	}
+	return categories, names
+}
+
+// generateCategories totals up the videos of each game, most viewed first
+func generateCategories(videos []twitch.Video, categories map[string]string, names map[string]string, boxArt map[string]string) []CategoryStats {
+	byGame := map[string]*CategoryStats{}
+	for _, video := range videos {
+		game := categories[video.ID]
+		category, ok := byGame[game]
+		if !ok {
+			category = &CategoryStats{GameID: game, GameName: names[game], BoxArtURL: boxArt[game]}
+			byGame[game] = category
+		}
+		category.Hours += video.Duration.Hours()
+		category.VideoCount++
+		category.TotalViews += video.Views
+	}
+
+	result := make([]CategoryStats, 0, len(byGame))
+	for _, category := range byGame {
+		category.MeanViews = category.TotalViews / category.VideoCount
+		result = append(result, *category)
+	}
+	slices.SortFunc(result, func(a, b CategoryStats) int {
+		return cmp.Or(cmp.Compare(b.TotalViews, a.TotalViews), cmp.Compare(a.GameID, b.GameID))
+	})
+	return result
+}
+
+// categoriseRanked labels ranked videos with the game they were streamed under
+func categoriseRanked(ranked []RankedVideo, categories map[string]string, breakdown []CategoryStats) {
+	names := map[string]string{}
+	for _, category := range breakdown {
+		names[category.GameID] = category.GameName
+	}
+	for i := range ranked {
+		ranked[i].GameID = categories[ranked[i].ID]
+		ranked[i].GameName = names[ranked[i].GameID]
+	}
+}
@@ -0,0 +1,162 @@
+package routes
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+func categorySamples() []storage.ViewerSample {
+	return []storage.ViewerSample{
+		{UserID: "testchannel", StreamID: "s1", GameID: "1", GameName: "Chess", RecordedAt: at("2024-01-01T00:00:00Z")},
+		{UserID: "testchannel", StreamID: "s1", GameID: "2", GameName: "Just Chatting", RecordedAt: at("2024-01-01T01:00:00Z")},
+		{UserID: "testchannel", StreamID: "s1", GameID: "2", GameName: "Just Chatting", RecordedAt: at("2024-01-01T02:00:00Z")},
+		{UserID: "testchannel", StreamID: "s2", GameID: "1", GameName: "Chess", RecordedAt: at("2024-01-02T00:00:00Z")},
+		{UserID: "testchannel", StreamID: "s3", GameID: "3", GameName: "Go", RecordedAt: at("2024-01-03T00:00:00Z")},
+		{UserID: "testchannel", StreamID: "s3", GameID: "1", GameName: "Chess", RecordedAt: at("2024-01-03T01:00:00Z")},
+	}
+}
+
+func categoryVideos() []twitch.Video {
+	return []twitch.Video{
+		{ID: "v1", UserID: "testchannel", StreamID: "s1", Views: 100, Duration: duration("2h"), CreatedAt: at("2024-01-01T00:00:00Z")},
+		{ID: "v2", UserID: "testchannel", StreamID: "s2", Views: 300, Duration: duration("1h"), CreatedAt: at("2024-01-02T00:00:00Z")},
+		{ID: "v3", UserID: "testchannel", StreamID: "s3", Views: 50, Duration: duration("30m"), CreatedAt: at("2024-01-03T00:00:00Z")},
+		{ID: "v4", UserID: "testchannel", StreamID: "unsampled", Views: 20, Duration: duration("1h"), CreatedAt: at("2024-01-04T00:00:00Z")},
+		{ID: "v5", UserID: "testchannel", Views: 10, Duration: duration("30m"), CreatedAt: at("2024-01-05T00:00:00Z")},
+	}
+}
+
+func TestVideoCategories(t *testing.T) {
+	categories, names := videoCategories(categoryVideos(), categorySamples())
+
+	if !(len(categories) == 3 && categories["v1"] == "2" && categories["v2"] == "1" && categories["v3"] == "3" &&
+		len(names) == 3 && names["2"] == "Just Chatting" && names["3"] == "Go") {
+		t.Errorf(`TestVideoCategories failed - categories: %v | names: %v`, categories, names)
+	}
+}
+
+func TestGenerateCategories(t *testing.T) {
+	categories := map[string]string{"v1": "2", "v2": "1", "v3": "1"}
+	names := map[string]string{"1": "Chess", "2": "Just Chatting"}
+	result := generateCategories(categoryVideos(), categories, names, map[string]string{"1": "art"})
+
+	if !(len(result) == 3 &&
+		result[0].GameID == "1" && result[0].GameName == "Chess" && result[0].BoxArtURL == "art" && result[0].VideoCount == 2 &&
+		result[0].TotalViews == 350 && result[0].MeanViews == 175 && floatCompare(result[0].Hours, 1.5) &&
+		result[1].GameID == "2" && result[1].TotalViews == 100 && floatCompare(result[1].Hours, 2) &&
+		result[2].GameID == "" && result[2].GameName == "" && result[2].VideoCount == 2 && result[2].TotalViews == 30 && floatCompare(result[2].Hours, 1.5)) {
+		t.Errorf(`TestGenerateCategories failed - result: %+v`, result)
+	}
+}
+
+func categoriesRequest(query string, service *MockTwitchService) *httptest.ResponseRecorder {
+	store := storage.NewMemoryStore()
+	store.RecordViewerSamples(categorySamples())
+
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats?limit=100"+query, nil)
+
+	RouteGetStreamerStats(c, *slog.Default(), service, store)
+	return response
+}
+
+func TestRouteCategories(t *testing.T) {
+	service := mockService(categoryVideos(), nil)
+	service.games = []twitch.Game{
+		{ID: "1", Name: "Chess", BoxArtURL: "https://static-cdn.jtvnw.net/ttv-boxart/1-{width}x{height}.jpg"},
+		{ID: "2", Name: "Just Chatting", BoxArtURL: "https://static-cdn.jtvnw.net/ttv-boxart/2-{width}x{height}.jpg"},
+	}
+	response := categoriesRequest("&categories=true&top=1", &service)
+
+	var body Stats
+	json.NewDecoder(response.Body).Decode(&body)
+
+	if !(response.Code == 200 && len(body.Categories) == 4 && len(service.gamesIds) == 1 && len(service.gamesIds[0]) == 3 &&
+		body.Categories[0].GameID == "1" && body.Categories[0].BoxArtURL == "https://static-cdn.jtvnw.net/ttv-boxart/1-285x380.jpg" &&
+		body.Categories[2].GameID == "3" && body.Categories[2].GameName == "Go" && body.Categories[2].BoxArtURL == "" &&
+		len(body.TopVideos) == 1 && body.TopVideos[0].GameID == "1" && body.TopVideos[0].GameName == "Chess") {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
+
+func TestRouteCategoriesGamesFailure(t *testing.T) {
+	service := mockService(categoryVideos(), nil)
+	service.gamesErr = &twitch.UpstreamUnavailableError{}
+	response := categoriesRequest("&categories=true", &service)
+
+	var body Stats
+	json.NewDecoder(response.Body).Decode(&body)
+
+	if !(response.Code == 200 && len(body.Categories) == 4 && body.Categories[0].GameName == "Chess" && body.Categories[0].BoxArtURL == "") {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
+
+func TestRouteCategoriesNotRequested(t *testing.T) {
+	service := mockService(categoryVideos(), nil)
+	response := categoriesRequest("&top=1", &service)
+
+	var body map[string]any
+	json.NewDecoder(response.Body).Decode(&body)
+	top, _ := body["topVideos"].([]any)
+
+	_, hasCategories := body["categories"]
+	_, hasGame := top[0].(map[string]any)["gameId"]
+	if !(response.Code == 200 && !hasCategories && !hasGame && len(service.gamesIds) == 0) {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
+
+func TestRouteCategoriesOnlyReadsSamplesSinceOldestVideo(t *testing.T) {
+	// v2 alone was created after the s1 samples, so they can't be read
+	service := mockService(categoryVideos()[1:2], nil)
+	store := storage.NewMemoryStore()
+	store.RecordViewerSamples(append(categorySamples(),
+		storage.ViewerSample{UserID: "testchannel", StreamID: "s2", GameID: "2", RecordedAt: at("2023-12-01T00:00:00Z")},
+		storage.ViewerSample{UserID: "testchannel", StreamID: "s2", GameID: "2", RecordedAt: at("2023-12-01T01:00:00Z")},
+	))
+
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats?limit=100&categories=true", nil)
+	RouteGetStreamerStats(c, *slog.Default(), &service, store)
+
+	var body Stats
+	json.NewDecoder(response.Body).Decode(&body)
+
+	if !(response.Code == 200 && len(body.Categories) == 1 && body.Categories[0].GameID == "1") {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
+
+func TestRouteCategoriesNoneKnown(t *testing.T) {
+	service := mockService(categoryVideos()[3:], nil)
+	response := categoriesRequest("&categories=true", &service)
+
+	var body Stats
+	json.NewDecoder(response.Body).Decode(&body)
+
+	// The stats are still returned, with every video in the unknown group
+	if !(response.Code == 200 && body.VideoCount == 2 && len(body.Categories) == 1 &&
+		body.Categories[0].GameID == "" && body.Categories[0].VideoCount == 2 && len(service.gamesIds) == 0) {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
+
+func TestRouteCategoriesInvalid(t *testing.T) {
+	service := mockService(categoryVideos(), nil)
+	response := categoriesRequest("&categories=yes", &service)
+
+	if !(response.Code == 400 && errResponse(response).Errors[0] == "Invalid categories parameter") {
+		t.Errorf(`Route test failed - Status %d (expected 400)`, response.Code)
+	}
+}
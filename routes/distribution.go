@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"math"
+	"slices"
+)
+
+type Distribution struct {
+	Min                    float64 `json:"min"`
+	P25                    float64 `json:"p25"`
+	Median                 float64 `json:"median"`
+	P75                    float64 `json:"p75"`
+	P90                    float64 `json:"p90"`
+	P99                    float64 `json:"p99"`
+	Max                    float64 `json:"max"`
+	StdDev                 float64 `json:"stdDev"`
+	CoefficientOfVariation float64 `json:"coefficientOfVariation"`
+}
+
+func generateDistribution(values []float64) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	var total float64 = 0
+	for _, value := range sorted {
+		total = total + value
+	}
+	mean := total / float64(len(sorted))
+
+	var squares float64 = 0
+	for _, value := range sorted {
+		squares = squares + (value-mean)*(value-mean)
+	}
+	// Population rather than sample deviation, the videos are all we're describing
+	stdDev := math.Sqrt(squares / float64(len(sorted)))
+
+	var cv float64 = 0
+	if mean != 0 {
+		cv = stdDev / mean
+	}
+
+	return Distribution{
+		Min:                    sorted[0],
+		P25:                    percentile(sorted, 0.25),
+		Median:                 percentile(sorted, 0.5),
+		P75:                    percentile(sorted, 0.75),
+		P90:                    percentile(sorted, 0.9),
+		P99:                    percentile(sorted, 0.99),
+		Max:                    sorted[len(sorted)-1],
+		StdDev:                 stdDev,
+		CoefficientOfVariation: cv,
+	}
+}
+
+// percentile linearly interpolates between the closest ranks of an already
+// sorted slice, so the median of an even number of values is their midpoint
+func percentile(sorted []float64, p float64) float64 {
+	rank := p * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"testing"
+
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+func compareDistribution(t *testing.T, name string, expected Distribution, reality Distribution) {
+	if !(floatCompare(reality.Min, expected.Min) &&
+		floatCompare(reality.P25, expected.P25) &&
+		floatCompare(reality.Median, expected.Median) &&
+		floatCompare(reality.P75, expected.P75) &&
+		floatCompare(reality.P90, expected.P90) &&
+		floatCompare(reality.P99, expected.P99) &&
+		floatCompare(reality.Max, expected.Max) &&
+		floatCompare(reality.StdDev, expected.StdDev) &&
+		floatCompare(reality.CoefficientOfVariation, expected.CoefficientOfVariation)) {
+		t.Errorf(`generateDistribution(%s) should return %+v but returns %+v`, name, expected, reality)
+	}
+}
+
+func TestGenerateDistribution(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []float64
+		expected Distribution
+	}{
+		{"empty", []float64{}, Distribution{}},
+		{"single", []float64{5}, Distribution{Min: 5, P25: 5, Median: 5, P75: 5, P90: 5, P99: 5, Max: 5}},
+		{"all zero", []float64{0, 0, 0}, Distribution{}},
+		{"even count", []float64{1, 2, 3, 4}, Distribution{
+			Min: 1, P25: 1.75, Median: 2.5, P75: 3.25, P90: 3.7, P99: 3.97, Max: 4,
+			StdDev: 1.11803, CoefficientOfVariation: 0.44721,
+		}},
+		{"unsorted", []float64{10, 0, 5}, Distribution{
+			Min: 0, P25: 2.5, Median: 5, P75: 7.5, P90: 9, P99: 9.9, Max: 10,
+			StdDev: 4.08248, CoefficientOfVariation: 0.81650,
+		}},
+		{"outlier", []float64{100, 100, 100, 100, 10000}, Distribution{
+			Min: 100, P25: 100, Median: 100, P75: 100, P90: 6040, P99: 9604, Max: 10000,
+			StdDev: 3960, CoefficientOfVariation: 1.90385,
+		}},
+	}
+
+	for _, test := range tests {
+		compareDistribution(t, test.name, test.expected, generateDistribution(test.values))
+	}
+}
+
+func TestGenerateDistributionDoesNotReorderInput(t *testing.T) {
+	values := []float64{3, 1, 2}
+	generateDistribution(values)
+
+	if !(values[0] == 3 && values[1] == 1 && values[2] == 2) {
+		t.Errorf(`generateDistribution(values) reordered its input to %v`, values)
+	}
+}
+
+func TestGenerateStatsDistributions(t *testing.T) {
+	videos := []twitch.Video{
+		{Title: "Title 1", Views: 100, Duration: duration("1m")},
+		{Title: "Title 2", Views: 200, Duration: duration("2m")},
+		{Title: "Title 3", Views: 300, Duration: duration("3m")},
+	}
+	result := generateStats(videos)
+
+	compareDistribution(t, "views", Distribution{
+		Min: 100, P25: 150, Median: 200, P75: 250, P90: 280, P99: 298, Max: 300,
+		StdDev: 81.64966, CoefficientOfVariation: 0.40825,
+	}, result.Views)
+	compareDistribution(t, "duration", Distribution{
+		Min: 60, P25: 90, Median: 120, P75: 150, P90: 168, P99: 178.8, Max: 180,
+		StdDev: 48.98979, CoefficientOfVariation: 0.40825,
+	}, result.Duration)
+}
@@ -0,0 +1,91 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+// Machine readable error codes, sent alongside the human readable errors so
+// clients don't have to match on messages
+const (
+	CodeInvalidRequest       = "invalid_request"
+	CodeUserNotFound         = "user_not_found"
+	CodeNoVideos             = "no_videos"
+	CodeNoClips              = "no_clips"
+	CodeNotWatched           = "not_watched"
+	CodeUpstreamRejected     = "upstream_rejected"
+	CodeUpstreamUnauthorized = "upstream_unauthorized"
+	CodeRateLimited          = "rate_limited"
+	CodeUpstreamUnavailable  = "upstream_unavailable"
+	CodeUpstreamTimeout      = "upstream_timeout"
+	CodeUpstreamBadResponse  = "upstream_bad_response"
+	CodeInternal             = "internal_error"
+)
+
+const (
+	MessageUpstreamRejected     = "Twitch rejected the request, check the channel ID and parameters"
+	MessageUpstreamUnauthorized = "Could not authenticate with Twitch"
+	MessageRateLimited          = "Rate limited by Twitch, try again later"
+	MessageUpstreamUnavailable  = "Twitch is unavailable"
+	MessageUpstreamTimeout      = "Timed out waiting for Twitch"
+	MessageUpstreamBadResponse  = "Could not read the response from Twitch"
+)
+
+// errorResponse is how an error from the twitch service is reported
+type errorResponse struct {
+	status     int
+	code       string
+	message    string
+	retryAfter time.Duration
+}
+
+func classifyError(err error) errorResponse {
+	var (
+		notFound     *twitch.UserNotFoundError
+		invalid      *twitch.InvalidInputError
+		unauthorized *twitch.UnauthorizedError
+		oauth        *twitch.OAuthError
+		rateLimited  *twitch.RateLimitedError
+		unavailable  *twitch.UpstreamUnavailableError
+		decode       *twitch.DecodeError
+	)
+	switch {
+	case errors.As(err, &notFound):
+		return errorResponse{status: http.StatusNotFound, code: CodeUserNotFound, message: MessageNoUser}
+	case errors.As(err, &invalid):
+		return errorResponse{status: http.StatusBadRequest, code: CodeUpstreamRejected, message: MessageUpstreamRejected}
+	case errors.As(err, &unauthorized), errors.As(err, &oauth):
+		return errorResponse{status: http.StatusBadGateway, code: CodeUpstreamUnauthorized, message: MessageUpstreamUnauthorized}
+	case errors.As(err, &rateLimited):
+		return errorResponse{status: http.StatusServiceUnavailable, code: CodeRateLimited, message: MessageRateLimited, retryAfter: rateLimited.RetryAfter}
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &unavailable) && unavailable.Timeout():
+		return errorResponse{status: http.StatusGatewayTimeout, code: CodeUpstreamTimeout, message: MessageUpstreamTimeout}
+	case errors.As(err, &unavailable):
+		return errorResponse{status: http.StatusBadGateway, code: CodeUpstreamUnavailable, message: MessageUpstreamUnavailable}
+	case errors.As(err, &decode):
+		return errorResponse{status: http.StatusBadGateway, code: CodeUpstreamBadResponse, message: MessageUpstreamBadResponse}
+	default:
+		return errorResponse{status: http.StatusInternalServerError, code: CodeInternal, message: MessageUnknown}
+	}
+}
+
+func respondError(c *gin.Context, status int, code string, messages ...string) {
+	c.JSON(status, ErrorResponseBody{Errors: messages, Code: code})
+}
+
+// respondTwitchError writes the response for an error from the twitch service,
+// telling the client when to retry if twitch said so
+func respondTwitchError(c *gin.Context, err error) {
+	response := classifyError(err)
+	if response.retryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(response.retryAfter.Seconds()))))
+	}
+	respondError(c, response.status, response.code, response.message)
+}
@@ -0,0 +1,74 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{&twitch.UserNotFoundError{Channel: "nobody"}, 404, CodeUserNotFound},
+		{&twitch.InvalidInputError{Err: &twitch.ApiError{StatusCode: 400}}, 400, CodeUpstreamRejected},
+		{&twitch.UnauthorizedError{StatusCode: 401}, 502, CodeUpstreamUnauthorized},
+		{&twitch.OAuthError{StatusCode: 400}, 502, CodeUpstreamUnauthorized},
+		{&twitch.RateLimitedError{RetryAfter: time.Second}, 503, CodeRateLimited},
+		{&twitch.UpstreamUnavailableError{Err: &twitch.ApiError{StatusCode: 503}}, 502, CodeUpstreamUnavailable},
+		{&twitch.UpstreamUnavailableError{Err: context.DeadlineExceeded}, 504, CodeUpstreamTimeout},
+		{fmt.Errorf("paging: %w", context.DeadlineExceeded), 504, CodeUpstreamTimeout},
+		{&twitch.DecodeError{Path: "videos", Err: errors.New("unexpected EOF")}, 502, CodeUpstreamBadResponse},
+		{&twitch.ApiError{StatusCode: 418}, 500, CodeInternal},
+	}
+
+	for _, test := range tests {
+		if result := classifyError(test.err); !(result.status == test.status && result.code == test.code && result.message != "") {
+			t.Errorf(`classifyError(%v) should return %d %s but returns %+v`, test.err, test.status, test.code, result)
+		}
+	}
+}
+
+func TestRouteRateLimited(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats?limit=10", nil)
+
+	service := mockService([]twitch.Video{}, &twitch.RateLimitedError{RetryAfter: 1500 * time.Millisecond})
+
+	RouteGetStreamerStats(c, *slog.Default(), &service, storage.NewMemoryStore())
+
+	err := errResponse(response)
+
+	if !(response.Code == 503 && response.Header().Get("Retry-After") == "2" && err.Code == CodeRateLimited && err.Errors[0] == MessageRateLimited) {
+		t.Errorf(`Route test failed - Status %d (expected 503) | Retry-After %q | Body %v`, response.Code, response.Header().Get("Retry-After"), err)
+	}
+}
+
+func TestRouteUpstreamTimeout(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats/heatmap?limit=10", nil)
+
+	service := mockService([]twitch.Video{}, &twitch.UpstreamUnavailableError{Err: context.DeadlineExceeded})
+
+	RouteGetStreamerHeatmap(c, *slog.Default(), &service)
+
+	err := errResponse(response)
+
+	if !(response.Code == 504 && response.Header().Get("Retry-After") == "" && err.Code == CodeUpstreamTimeout) {
+		t.Errorf(`Route test failed - Status %d (expected 504) | Body %v`, response.Code, err)
+	}
+}
@@ -0,0 +1,152 @@
+package routes
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	MaxCompareChannels    = 10
+	MaxCompareConcurrency = 4
+)
+
+type ChannelComparison struct {
+	Channel string `json:"channel"`
+	Stats   *Stats `json:"stats,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Code    string `json:"code,omitempty"`
+}
+
+// Rankings hold, for each metric, the channels that succeeded ordered from
+// highest to lowest
+type Comparison struct {
+	Channels []ChannelComparison `json:"channels"`
+	Rankings map[string][]string `json:"rankings"`
+}
+
+var compareMetrics = map[string]func(Stats) float64{
+	"totalViews":     func(s Stats) float64 { return float64(s.TotalViews) },
+	"meanViews":      func(s Stats) float64 { return float64(s.MeanViews) },
+	"medianViews":    func(s Stats) float64 { return s.Views.Median },
+	"totalLength":    func(s Stats) float64 { return float64(s.TotalLength) },
+	"viewsPerMinute": func(s Stats) float64 { return s.ViewsPerMinute },
+}
+
+func RouteGetCompare(c *gin.Context, log slog.Logger, twitch ITwitch) {
+
+	input := parseQuery(c)
+	channels := parseChannels(c.Query("channels"))
+
+	if len(channels) == 0 {
+		input.errors = append(input.errors, "Missing channels parameter")
+	}
+	if len(channels) > MaxCompareChannels {
+		input.errors = append(input.errors, fmt.Sprintf("Too many channels, the maximum is %d", MaxCompareChannels))
+	}
+
+	if len(input.errors) > 0 {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, input.errors...)
+		return
+	}
+
+	comparison := Comparison{
+		Channels: compareChannels(requestContext(c), log, twitch, channels, input),
+	}
+	comparison.Rankings = rankChannels(comparison.Channels)
+
+	log.Debug("Returning comparison", "channels", channels)
+
+	c.JSON(http.StatusOK, comparison)
+}
+
+// parseChannels splits the comma separated list, dropping blanks and
+// duplicates but otherwise keeping the order given
+func parseChannels(value string) []string {
+	channels := []string{}
+	for _, channel := range strings.Split(value, ",") {
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		if channel != "" && !slices.Contains(channels, channel) {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+func compareChannels(ctx context.Context, log slog.Logger, service ITwitch, channels []string, input parsedInput) []ChannelComparison {
+	results := make([]ChannelComparison, len(channels))
+	limiter := make(chan struct{}, MaxCompareConcurrency)
+
+	var wg sync.WaitGroup
+	for i, channel := range channels {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limiter <- struct{}{}
+			defer func() { <-limiter }()
+
+			results[i] = compareChannel(ctx, log, service, channel, input)
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+func compareChannel(ctx context.Context, log slog.Logger, service ITwitch, channel string, input parsedInput) ChannelComparison {
+	userId, err := service.ResolveUserId(ctx, channel)
+	if err != nil {
+		response := classifyError(err)
+		if response.code != CodeUserNotFound {
+			log.Warn("Failed to resolve channel for comparison", "channel", channel, "err", err)
+		}
+		return ChannelComparison{Channel: channel, Error: response.message, Code: response.code}
+	}
+
+	videos, err := service.GetUserVideos(ctx, userId, input.limit, input.filter)
+	if err != nil {
+		log.Warn("Failed to fetch videos for comparison", "channel", channel, "err", err)
+		response := classifyError(err)
+		return ChannelComparison{Channel: channel, Error: response.message, Code: response.code}
+	}
+	if len(videos) == 0 {
+		return ChannelComparison{Channel: channel, Error: MessageNoVideos, Code: CodeNoVideos}
+	}
+
+	stats := generateStats(videos)
+	return ChannelComparison{Channel: channel, Stats: &stats}
+}
+
+// rankChannels orders the successful channels on every metric, ties are
+// broken alphabetically so the rankings are stable between requests
+func rankChannels(comparisons []ChannelComparison) map[string][]string {
+	ranked := []ChannelComparison{}
+	for _, comparison := range comparisons {
+		if comparison.Stats != nil {
+			ranked = append(ranked, comparison)
+		}
+	}
+
+	rankings := map[string][]string{}
+	for metric, value := range compareMetrics {
+		slices.SortFunc(ranked, func(a, b ChannelComparison) int {
+			return cmp.Or(
+				cmp.Compare(value(*b.Stats), value(*a.Stats)),
+				strings.Compare(a.Channel, b.Channel),
+			)
+		})
+		order := make([]string, 0, len(ranked))
+		for _, comparison := range ranked {
+			order = append(order, comparison.Channel)
+		}
+		rankings[metric] = order
+	}
+	return rankings
+}
@@ -0,0 +1,169 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+// Safe for concurrent use, and tracks how many fetches overlapped
+type MockCompareService struct {
+	lock     sync.Mutex
+	videos   map[string][]twitch.Video
+	errs     map[string]error
+	inFlight int
+	peak     int
+}
+
+func (m *MockCompareService) ResolveUserId(ctx context.Context, channel string) (string, error) {
+	if _, ok := m.videos[channel]; !ok {
+		if _, ok := m.errs[channel]; !ok {
+			return "", &twitch.UserNotFoundError{Channel: channel}
+		}
+	}
+	return channel, nil
+}
+
+func (m *MockCompareService) GetUserVideos(ctx context.Context, userId string, limit int, filter twitch.VideoFilter) ([]twitch.Video, error) {
+	m.lock.Lock()
+	m.inFlight++
+	m.peak = max(m.peak, m.inFlight)
+	m.lock.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	m.lock.Lock()
+	m.inFlight--
+	m.lock.Unlock()
+	return m.videos[userId], m.errs[userId]
+}
+
+func compareRequest(query string, service ITwitch) (*httptest.ResponseRecorder, Comparison) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Request = httptest.NewRequest("GET", "localhost:3000/compare?"+query, nil)
+
+	RouteGetCompare(c, *slog.Default(), service)
+
+	var body Comparison
+	json.Unmarshal(response.Body.Bytes(), &body)
+	return response, body
+}
+
+func TestParseChannels(t *testing.T) {
+	result := parseChannels(" Alpha,beta,,alpha , gamma,")
+
+	if !slices.Equal(result, []string{"alpha", "beta", "gamma"}) {
+		t.Errorf(`parseChannels should return [alpha beta gamma] but returns %v`, result)
+	}
+}
+
+func TestRouteCompareMissingParams(t *testing.T) {
+	response, _ := compareRequest("", &MockCompareService{})
+
+	err := ErrorResponseBody{}
+	json.Unmarshal(response.Body.Bytes(), &err)
+
+	if !(response.Code == 400 && len(err.Errors) == 2) {
+		t.Errorf(`Route test failed - Status %d (expected 400) | Body %v`, response.Code, err)
+	}
+}
+
+func TestRouteCompareTooManyChannels(t *testing.T) {
+	response, _ := compareRequest("limit=10&channels=a,b,c,d,e,f,g,h,i,j,k", &MockCompareService{})
+
+	if response.Code != 400 {
+		t.Errorf(`Route test failed - Status %d (expected 400)`, response.Code)
+	}
+}
+
+func TestRouteCompareNegativeLimit(t *testing.T) {
+	service := &MockCompareService{videos: map[string][]twitch.Video{"a": {}, "b": {}}}
+	response, _ := compareRequest("limit=-5&channels=a,b", service)
+
+	if !(response.Code == 400 && service.peak == 0) {
+		t.Errorf(`Route test failed - Status %d (expected 400) | Fetches %d`, response.Code, service.peak)
+	}
+}
+
+func TestRouteCompare(t *testing.T) {
+	service := &MockCompareService{
+		videos: map[string][]twitch.Video{
+			"alpha": {{Title: "A", Views: 100, Duration: duration("1h")}, {Title: "B", Views: 300, Duration: duration("1h")}},
+			"beta":  {{Title: "C", Views: 1000, Duration: duration("10h")}},
+			"gamma": {{Title: "D", Views: 400, Duration: duration("30m")}},
+			"empty": {},
+		},
+		errs: map[string]error{"broken": &twitch.ApiError{StatusCode: 500}},
+	}
+
+	response, body := compareRequest("limit=10&channels=alpha,beta,gamma,empty,broken,nobody", service)
+
+	if !(response.Code == 200 && len(body.Channels) == 6) {
+		t.Fatalf(`Route test failed - Status %d (expected 200) | Body %s`, response.Code, response.Body.String())
+	}
+
+	errs, codes := []string{}, []string{}
+	for _, channel := range body.Channels {
+		errs = append(errs, channel.Error)
+		codes = append(codes, channel.Code)
+	}
+	if !slices.Equal(errs, []string{"", "", "", MessageNoVideos, MessageUnknown, MessageNoUser}) {
+		t.Errorf(`Route test failed - per channel errors %v`, errs)
+	}
+	if !slices.Equal(codes, []string{"", "", "", CodeNoVideos, CodeInternal, CodeUserNotFound}) {
+		t.Errorf(`Route test failed - per channel codes %v`, codes)
+	}
+	if body.Channels[0].Stats.TotalViews != 400 || body.Channels[3].Stats != nil {
+		t.Errorf(`Route test failed - channel results %+v`, body.Channels)
+	}
+
+	expected := map[string][]string{
+		"totalViews":     {"beta", "alpha", "gamma"},
+		"meanViews":      {"beta", "gamma", "alpha"},
+		"medianViews":    {"beta", "gamma", "alpha"},
+		"totalLength":    {"beta", "alpha", "gamma"},
+		"viewsPerMinute": {"gamma", "alpha", "beta"},
+	}
+	for metric, order := range expected {
+		if !slices.Equal(body.Rankings[metric], order) {
+			t.Errorf(`Route test failed - %s ranking should be %v but is %v`, metric, order, body.Rankings[metric])
+		}
+	}
+}
+
+func TestRankChannelsTieBreak(t *testing.T) {
+	stats := Stats{TotalViews: 100}
+	rankings := rankChannels([]ChannelComparison{
+		{Channel: "zeta", Stats: &stats},
+		{Channel: "alpha", Stats: &stats},
+		{Channel: "failed", Error: MessageUnknown},
+	})
+
+	if !slices.Equal(rankings["totalViews"], []string{"alpha", "zeta"}) {
+		t.Errorf(`rankChannels tie break should give [alpha zeta] but gives %v`, rankings["totalViews"])
+	}
+}
+
+func TestCompareChannelsBoundedConcurrency(t *testing.T) {
+	service := &MockCompareService{videos: map[string][]twitch.Video{}}
+	channels := []string{}
+	for _, channel := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		service.videos[channel] = []twitch.Video{{Views: 1, Duration: duration("1m")}}
+		channels = append(channels, channel)
+	}
+
+	results := compareChannels(context.Background(), *slog.Default(), service, channels, parsedInput{limit: 10})
+
+	if !(len(results) == 10 && service.peak > 1 && service.peak <= MaxCompareConcurrency) {
+		t.Errorf(`compareChannels should run up to %d fetches at once but peaked at %d`, MaxCompareConcurrency, service.peak)
+	}
+}
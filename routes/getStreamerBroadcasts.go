@@ -0,0 +1,100 @@
+package routes
+
+import (
+	"cmp"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+)
+
+// BroadcastViewers summarises the concurrent viewer samples of one broadcast.
+// The average is the mean of the samples, which are taken at a steady interval.
+type BroadcastViewers struct {
+	StreamID       string    `json:"streamId"`
+	Title          string    `json:"title"`
+	GameName       string    `json:"gameName"`
+	StartedAt      time.Time `json:"startedAt"`
+	LastSampled    time.Time `json:"lastSampled"`
+	Samples        int       `json:"samples"`
+	PeakViewers    int       `json:"peakViewers"`
+	AverageViewers float64   `json:"averageViewers"`
+}
+
+type Broadcasts struct {
+	From       time.Time          `json:"from"`
+	To         time.Time          `json:"to"`
+	Broadcasts []BroadcastViewers `json:"broadcasts"`
+}
+
+func RouteGetStreamerBroadcasts(c *gin.Context, log slog.Logger, service IUserResolver, store IViewerSamples) {
+
+	channelId := c.Param("channelId")
+
+	errors := []string{}
+	if len(channelId) == 0 {
+		errors = append(errors, "Missing channel ID")
+	}
+	from, to, rangeErrors := parseRange(c)
+	errors = append(errors, rangeErrors...)
+
+	if len(errors) > 0 {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, errors...)
+		return
+	}
+
+	userId, ok := resolveUserId(c, service, channelId)
+	if !ok {
+		return
+	}
+
+	samples, err := store.ViewerSamples(userId, from, to)
+	if err != nil {
+		log.Error("Failed to read viewer samples", "userId", userId, "err", err)
+		respondError(c, http.StatusInternalServerError, CodeInternal, MessageUnknown)
+		return
+	}
+
+	broadcasts := Broadcasts{From: from, To: to, Broadcasts: summariseBroadcasts(samples)}
+
+	log.Debug("Returning broadcasts", "userId", userId, "samples", len(samples), "broadcasts", len(broadcasts.Broadcasts))
+
+	c.JSON(http.StatusOK, broadcasts)
+}
+
+// summariseBroadcasts groups samples by stream, most recently started first.
+// The title and game are taken from the latest sample as both can change
+// during a broadcast. Samples must be oldest first.
+func summariseBroadcasts(samples []storage.ViewerSample) []BroadcastViewers {
+	broadcasts := []BroadcastViewers{}
+	index := map[string]int{}
+	totals := map[string]int{}
+
+	for _, sample := range samples {
+		i, ok := index[sample.StreamID]
+		if !ok {
+			i = len(broadcasts)
+			index[sample.StreamID] = i
+			broadcasts = append(broadcasts, BroadcastViewers{StreamID: sample.StreamID, StartedAt: sample.StartedAt})
+		}
+		broadcast := &broadcasts[i]
+		broadcast.Title = sample.Title
+		broadcast.GameName = sample.GameName
+		broadcast.LastSampled = sample.RecordedAt
+		broadcast.Samples++
+		broadcast.PeakViewers = max(broadcast.PeakViewers, sample.ViewerCount)
+		totals[sample.StreamID] += sample.ViewerCount
+	}
+
+	for i := range broadcasts {
+		broadcasts[i].AverageViewers = float64(totals[broadcasts[i].StreamID]) / float64(broadcasts[i].Samples)
+	}
+	slices.SortFunc(broadcasts, func(a, b BroadcastViewers) int {
+		return cmp.Or(b.StartedAt.Compare(a.StartedAt), strings.Compare(a.StreamID, b.StreamID))
+	})
+	return broadcasts
+}
@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+func viewerSamples() []storage.ViewerSample {
+	first, second := at("2024-04-01T18:00:00Z"), at("2024-04-02T18:00:00Z")
+	return []storage.ViewerSample{
+		{UserID: "testchannel", StreamID: "s1", Title: "Day one", GameName: "Chess", ViewerCount: 100, StartedAt: first, RecordedAt: at("2024-04-01T18:05:00Z")},
+		{UserID: "testchannel", StreamID: "s1", Title: "Day one", GameName: "Chess", ViewerCount: 300, StartedAt: first, RecordedAt: at("2024-04-01T19:05:00Z")},
+		{UserID: "testchannel", StreamID: "s1", Title: "Day one!", GameName: "Just Chatting", ViewerCount: 200, StartedAt: first, RecordedAt: at("2024-04-01T20:05:00Z")},
+		{UserID: "testchannel", StreamID: "s2", Title: "Day two", GameName: "Chess", ViewerCount: 50, StartedAt: second, RecordedAt: at("2024-04-02T18:05:00Z")},
+	}
+}
+
+func TestSummariseBroadcasts(t *testing.T) {
+	broadcasts := summariseBroadcasts(viewerSamples())
+
+	if !(len(broadcasts) == 2 && broadcasts[0].StreamID == "s2" && broadcasts[0].Samples == 1 && broadcasts[0].PeakViewers == 50 &&
+		broadcasts[1].StreamID == "s1" && broadcasts[1].Samples == 3 && broadcasts[1].PeakViewers == 300 &&
+		floatCompare(broadcasts[1].AverageViewers, 200) && broadcasts[1].Title == "Day one!" && broadcasts[1].GameName == "Just Chatting" &&
+		broadcasts[1].LastSampled.Equal(at("2024-04-01T20:05:00Z"))) {
+		t.Errorf(`TestSummariseBroadcasts failed - broadcasts: %+v`, broadcasts)
+	}
+}
+
+func TestSummariseBroadcastsEmpty(t *testing.T) {
+	broadcasts := summariseBroadcasts(nil)
+
+	if !(broadcasts != nil && len(broadcasts) == 0) {
+		t.Errorf(`TestSummariseBroadcastsEmpty failed - broadcasts: %+v`, broadcasts)
+	}
+}
+
+func TestRouteBroadcasts(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/broadcasts?from=2024-04-02&to=2024-04-03", nil)
+
+	service := mockService([]twitch.Video{}, nil)
+	store := storage.NewMemoryStore()
+	store.RecordViewerSamples(viewerSamples())
+
+	RouteGetStreamerBroadcasts(c, *slog.Default(), &service, store)
+
+	var body Broadcasts
+	json.NewDecoder(response.Body).Decode(&body)
+
+	if !(response.Code == 200 && len(body.Broadcasts) == 1 && body.Broadcasts[0].StreamID == "s2") {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
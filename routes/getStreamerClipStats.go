@@ -0,0 +1,192 @@
+package routes
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+const (
+	DefaultTopClips = 10
+	MessageNoClips  = "No clips found for this user"
+)
+
+type IClipsTwitch interface {
+	IUserResolver
+	GetUserClips(context.Context, string, int, twitch.ClipFilter) ([]twitch.Clip, error)
+}
+
+type TopClip struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Views       int       `json:"views"`
+	CreatorName string    `json:"creatorName"`
+	VideoID     string    `json:"videoId,omitempty"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type Clipper struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Clips int    `json:"clips"`
+	Views int    `json:"views"`
+}
+
+type VideoClips struct {
+	VideoID string `json:"videoId"`
+	Clips   int    `json:"clips"`
+	Views   int    `json:"views"`
+}
+
+type ClipStats struct {
+	ClipCount     int          `json:"clipCount"`
+	TotalViews    int          `json:"totalViews"`
+	TopClips      []TopClip    `json:"topClips"`
+	TopClippers   []Clipper    `json:"topClippers"`
+	ClipsPerVideo []VideoClips `json:"clipsPerVideo"`
+	// UnlinkedClips were clipped from a video that has since been deleted,
+	// or from a broadcast that was never saved
+	UnlinkedClips int `json:"unlinkedClips"`
+}
+
+func RouteGetStreamerClipStats(c *gin.Context, log slog.Logger, service IClipsTwitch) {
+
+	channelId := c.Param("channelId")
+
+	errors := []string{}
+	if len(channelId) == 0 {
+		errors = append(errors, "Missing channel ID")
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		errors = append(errors, "Missing or invalid limit parameter")
+	}
+
+	filter := twitch.ClipFilter{}
+	var ok bool
+	if filter.StartedAt, ok = parseTime(c.Query("startedAt"), time.Time{}); !ok {
+		errors = append(errors, "Invalid startedAt parameter")
+	}
+	if filter.EndedAt, ok = parseEndTime(c.Query("endedAt"), time.Time{}); !ok {
+		errors = append(errors, "Invalid endedAt parameter")
+	}
+	if !filter.EndedAt.IsZero() && filter.StartedAt.IsZero() {
+		errors = append(errors, "endedAt requires startedAt")
+	} else if !filter.EndedAt.IsZero() && !filter.StartedAt.Before(filter.EndedAt) {
+		errors = append(errors, "startedAt must be before endedAt")
+	}
+
+	top := DefaultTopClips
+	if _, exists := c.GetQuery("top"); exists {
+		top = parseRankCount(c, "top", &errors)
+	}
+
+	if len(errors) > 0 {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, errors...)
+		return
+	}
+
+	userId, ok := resolveUserId(c, service, channelId)
+	if !ok {
+		return
+	}
+
+	clips, err := service.GetUserClips(requestContext(c), userId, limit, filter)
+	if err != nil {
+		respondTwitchError(c, err)
+		return
+	}
+	if len(clips) == 0 {
+		respondError(c, http.StatusNotFound, CodeNoClips, MessageNoClips)
+		return
+	}
+
+	stats := generateClipStats(clips, top)
+
+	log.Debug("Returning clip stats", "userId", userId, "clips", stats.ClipCount, "videos", len(stats.ClipsPerVideo))
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// generateClipStats ranks clips by views and clippers by how many clips they
+// made, keeping the top of each. Every video with clips is listed, most
+// clipped first. Ties are broken so the order is stable between requests.
+func generateClipStats(clips []twitch.Clip, top int) ClipStats {
+	stats := ClipStats{ClipCount: len(clips)}
+
+	clippers := map[string]*Clipper{}
+	videos := map[string]*VideoClips{}
+	for _, clip := range clips {
+		stats.TotalViews += clip.Views
+
+		clipper, ok := clippers[clip.CreatorID]
+		if !ok {
+			clipper = &Clipper{ID: clip.CreatorID, Name: clip.CreatorName}
+			clippers[clip.CreatorID] = clipper
+		}
+		clipper.Clips++
+		clipper.Views += clip.Views
+
+		if clip.VideoID == "" {
+			stats.UnlinkedClips++
+			continue
+		}
+		video, ok := videos[clip.VideoID]
+		if !ok {
+			video = &VideoClips{VideoID: clip.VideoID}
+			videos[clip.VideoID] = video
+		}
+		video.Clips++
+		video.Views += clip.Views
+	}
+
+	ranked := slices.Clone(clips)
+	slices.SortFunc(ranked, func(a, b twitch.Clip) int {
+		return cmp.Or(
+			cmp.Compare(b.Views, a.Views),
+			a.CreatedAt.Compare(b.CreatedAt),
+			strings.Compare(a.ID, b.ID),
+		)
+	})
+	stats.TopClips = make([]TopClip, 0, min(top, len(ranked)))
+	for _, clip := range ranked[:min(top, len(ranked))] {
+		stats.TopClips = append(stats.TopClips, TopClip{
+			ID:          clip.ID,
+			Title:       clip.Title,
+			Views:       clip.Views,
+			CreatorName: clip.CreatorName,
+			VideoID:     clip.VideoID,
+			URL:         clip.URL,
+			CreatedAt:   clip.CreatedAt,
+		})
+	}
+
+	stats.TopClippers = []Clipper{}
+	for _, clipper := range clippers {
+		stats.TopClippers = append(stats.TopClippers, *clipper)
+	}
+	slices.SortFunc(stats.TopClippers, func(a, b Clipper) int {
+		return cmp.Or(cmp.Compare(b.Clips, a.Clips), cmp.Compare(b.Views, a.Views), strings.Compare(a.Name, b.Name))
+	})
+	stats.TopClippers = stats.TopClippers[:min(top, len(stats.TopClippers))]
+
+	stats.ClipsPerVideo = []VideoClips{}
+	for _, video := range videos {
+		stats.ClipsPerVideo = append(stats.ClipsPerVideo, *video)
+	}
+	slices.SortFunc(stats.ClipsPerVideo, func(a, b VideoClips) int {
+		return cmp.Or(cmp.Compare(b.Clips, a.Clips), cmp.Compare(b.Views, a.Views), strings.Compare(a.VideoID, b.VideoID))
+	})
+
+	return stats
+}
@@ -0,0 +1,139 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+type MockClipsService struct {
+	stack   []string
+	filters []twitch.ClipFilter
+	clips   []twitch.Clip
+	err     error
+}
+
+func (m *MockClipsService) ResolveUserId(ctx context.Context, channel string) (string, error) {
+	return channel, nil
+}
+
+func (m *MockClipsService) GetUserClips(ctx context.Context, broadcasterId string, limit int, filter twitch.ClipFilter) ([]twitch.Clip, error) {
+	m.stack = append(m.stack, fmt.Sprintf("GetUserClips-%s-%d", broadcasterId, limit))
+	m.filters = append(m.filters, filter)
+	return m.clips, m.err
+}
+
+func testClips() []twitch.Clip {
+	return []twitch.Clip{
+		{ID: "a", Views: 50, CreatorID: "1", CreatorName: "one", VideoID: "v1", CreatedAt: at("2024-04-01T00:00:00Z")},
+		{ID: "b", Views: 200, CreatorID: "2", CreatorName: "two", VideoID: "v2", CreatedAt: at("2024-04-02T00:00:00Z")},
+		{ID: "c", Views: 50, CreatorID: "1", CreatorName: "one", VideoID: "v1", CreatedAt: at("2024-03-01T00:00:00Z")},
+		{ID: "d", Views: 10, CreatorID: "3", CreatorName: "three", CreatedAt: at("2024-04-03T00:00:00Z")},
+		{ID: "e", Views: 5, CreatorID: "2", CreatorName: "two", VideoID: "v3", CreatedAt: at("2024-04-04T00:00:00Z")},
+	}
+}
+
+func TestGenerateClipStats(t *testing.T) {
+	stats := generateClipStats(testClips(), 3)
+
+	topClips := []string{}
+	for _, clip := range stats.TopClips {
+		topClips = append(topClips, clip.ID)
+	}
+	clippers := []string{}
+	for _, clipper := range stats.TopClippers {
+		clippers = append(clippers, clipper.Name)
+	}
+	videos := []string{}
+	for _, video := range stats.ClipsPerVideo {
+		videos = append(videos, fmt.Sprintf("%s:%d:%d", video.VideoID, video.Clips, video.Views))
+	}
+
+	if !(stats.ClipCount == 5 && stats.TotalViews == 315 && stats.UnlinkedClips == 1 &&
+		slices.Equal(topClips, []string{"b", "c", "a"}) &&
+		slices.Equal(clippers, []string{"two", "one", "three"}) && stats.TopClippers[0].Clips == 2 && stats.TopClippers[0].Views == 205 &&
+		slices.Equal(videos, []string{"v1:2:100", "v2:1:200", "v3:1:5"})) {
+		t.Errorf(`TestGenerateClipStats failed - top: %v | clippers: %v | videos: %v | stats: %+v`, topClips, clippers, videos, stats)
+	}
+}
+
+func TestGenerateClipStatsTopLimit(t *testing.T) {
+	stats := generateClipStats(testClips(), 1)
+	none := generateClipStats(testClips(), 0)
+
+	if !(len(stats.TopClips) == 1 && len(stats.TopClippers) == 1 && len(stats.ClipsPerVideo) == 3 &&
+		none.TopClips != nil && len(none.TopClips) == 0 && len(none.TopClippers) == 0) {
+		t.Errorf(`TestGenerateClipStatsTopLimit failed - stats: %+v | none: %+v`, stats, none)
+	}
+}
+
+func clipStatsRequest(query string, service *MockClipsService) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/clips/stats?"+query, nil)
+
+	RouteGetStreamerClipStats(c, *slog.Default(), service)
+	return response
+}
+
+func TestRouteClipStatsSuccess(t *testing.T) {
+	service := &MockClipsService{clips: testClips()}
+	response := clipStatsRequest("limit=50&startedAt=2024-03-01&endedAt=2024-05-01T00:00:00Z", service)
+
+	var body ClipStats
+	json.NewDecoder(response.Body).Decode(&body)
+
+	if !(response.Code == 200 && body.ClipCount == 5 && len(body.TopClips) == 5 &&
+		service.stack[0] == "GetUserClips-testchannel-50" &&
+		service.filters[0].StartedAt.Equal(at("2024-03-01T00:00:00Z")) && service.filters[0].EndedAt.Equal(at("2024-05-01T00:00:00Z"))) {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v | filters %+v`, response.Code, body, service.filters)
+	}
+}
+
+func TestRouteClipStatsInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"limit=10&endedAt=2024-05-01",
+		"limit=10&startedAt=2024-05-01&endedAt=2024-04-01",
+		"limit=10&startedAt=soon",
+		"limit=10&top=500",
+	}
+
+	for _, query := range tests {
+		service := &MockClipsService{clips: testClips()}
+		response := clipStatsRequest(query, service)
+		err := errResponse(response)
+
+		if !(response.Code == 400 && err.Code == CodeInvalidRequest && len(service.stack) == 0) {
+			t.Errorf(`Route test failed for %q - Status %d (expected 400) | Body %v`, query, response.Code, err)
+		}
+	}
+}
+
+func TestRouteClipStatsNoClips(t *testing.T) {
+	service := &MockClipsService{clips: []twitch.Clip{}}
+	response := clipStatsRequest("limit=10", service)
+	err := errResponse(response)
+
+	if !(response.Code == 404 && err.Code == CodeNoClips) {
+		t.Errorf(`Route test failed - Status %d (expected 404) | Body %v`, response.Code, err)
+	}
+}
+
+func TestRouteClipStatsTwitchError(t *testing.T) {
+	service := &MockClipsService{err: &twitch.InvalidInputError{Err: &twitch.ApiError{StatusCode: 400}}}
+	response := clipStatsRequest("limit=10", service)
+	err := errResponse(response)
+
+	if !(response.Code == 400 && err.Code == CodeUpstreamRejected) {
+		t.Errorf(`Route test failed - Status %d (expected 400) | Body %v`, response.Code, err)
+	}
+}
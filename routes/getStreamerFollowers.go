@@ -0,0 +1,127 @@
+package routes
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+)
+
+// FollowerGrowthWindows are the periods, in days, growth is reported over
+var FollowerGrowthWindows = []int{7, 30, 90}
+
+// MinFollowerSampleInterval matches the follower sampler's default, so the
+// route never samples a channel more often than the sampler would
+const MinFollowerSampleInterval = time.Hour
+
+// FollowerBaselineTolerance is how long before a window's cutoff its baseline
+// may have been taken. Anything older would report growth over a much longer
+// period than the window claims.
+const FollowerBaselineTolerance = 24 * time.Hour
+
+type IFollowersTwitch interface {
+	IUserResolver
+	GetFollowerCount(context.Context, string) (int, error)
+}
+
+type IFollowerStore interface {
+	RecordFollowerSampleEvery(storage.FollowerSample, time.Duration) (bool, error)
+	FollowerSamples(string, time.Time, time.Time) ([]storage.FollowerSample, error)
+}
+
+// FollowerGrowth is the change in followers since the last sample taken at
+// least Days ago. The change is null unless such a sample was taken no more
+// than FollowerBaselineTolerance before that.
+type FollowerGrowth struct {
+	Days     int       `json:"days"`
+	Since    time.Time `json:"since,omitzero"`
+	Baseline *int      `json:"baseline"`
+	Change   *int      `json:"change"`
+	// Percent is also null when the baseline was 0
+	Percent *float64 `json:"percent"`
+}
+
+type Followers struct {
+	Total      int              `json:"total"`
+	RecordedAt time.Time        `json:"recordedAt"`
+	Growth     []FollowerGrowth `json:"growth"`
+}
+
+func RouteGetStreamerFollowers(c *gin.Context, log slog.Logger, service IFollowersTwitch, store IFollowerStore) {
+
+	channelId := c.Param("channelId")
+	if len(channelId) == 0 {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Missing channel ID")
+		return
+	}
+
+	userId, ok := resolveUserId(c, service, channelId)
+	if !ok {
+		return
+	}
+
+	total, err := service.GetFollowerCount(requestContext(c), userId)
+	if err != nil {
+		respondTwitchError(c, err)
+		return
+	}
+	now := time.Now().UTC()
+
+	// A lookup is also taken as a sample, so channels that are only ever
+	// requested still build up a history, but no more often than the sampler
+	// would take them however often the route is called
+	sample := storage.FollowerSample{UserID: userId, Followers: total, RecordedAt: now}
+	if _, err := store.RecordFollowerSampleEvery(sample, MinFollowerSampleInterval); err != nil {
+		log.Error("Failed to record follower sample", "userId", userId, "err", err)
+	}
+
+	// Only samples that could be the baseline for one of the windows
+	longest := slices.Max(FollowerGrowthWindows)
+	from := now.AddDate(0, 0, -longest).Add(-FollowerBaselineTolerance)
+	samples, err := store.FollowerSamples(userId, from, now.AddDate(0, 0, -FollowerGrowthWindows[0]))
+	if err != nil {
+		log.Error("Failed to read follower samples", "userId", userId, "err", err)
+		respondError(c, http.StatusInternalServerError, CodeInternal, MessageUnknown)
+		return
+	}
+
+	followers := Followers{Total: total, RecordedAt: now, Growth: generateFollowerGrowth(total, samples, now)}
+
+	log.Debug("Returning followers", "userId", userId, "total", total, "samples", len(samples))
+
+	c.JSON(http.StatusOK, followers)
+}
+
+// generateFollowerGrowth measures growth over each of FollowerGrowthWindows
+// up to now. Samples must be oldest first.
+func generateFollowerGrowth(total int, samples []storage.FollowerSample, now time.Time) []FollowerGrowth {
+	growth := make([]FollowerGrowth, 0, len(FollowerGrowthWindows))
+	for _, days := range FollowerGrowthWindows {
+		window := FollowerGrowth{Days: days}
+		cutoff := now.AddDate(0, 0, -days)
+
+		var baseline *storage.FollowerSample
+		for i := range samples {
+			if samples[i].RecordedAt.After(cutoff) {
+				break
+			}
+			baseline = &samples[i]
+		}
+		if baseline != nil && !baseline.RecordedAt.Before(cutoff.Add(-FollowerBaselineTolerance)) {
+			change := total - baseline.Followers
+			window.Since = baseline.RecordedAt
+			window.Baseline = &baseline.Followers
+			window.Change = &change
+			if baseline.Followers > 0 {
+				percent := float64(change) / float64(baseline.Followers) * 100
+				window.Percent = &percent
+			}
+		}
+		growth = append(growth, window)
+	}
+	return growth
+}
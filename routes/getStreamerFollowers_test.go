@@ -0,0 +1,117 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+type MockFollowersService struct {
+	total int
+	err   error
+}
+
+func (m *MockFollowersService) ResolveUserId(ctx context.Context, channel string) (string, error) {
+	return channel, nil
+}
+
+func (m *MockFollowersService) GetFollowerCount(ctx context.Context, userId string) (int, error) {
+	return m.total, m.err
+}
+
+func followersRequest(service *MockFollowersService, store IFollowerStore) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/followers", nil)
+
+	RouteGetStreamerFollowers(c, *slog.Default(), service, store)
+	return response
+}
+
+func TestRouteFollowers(t *testing.T) {
+	now := time.Now().UTC()
+	store := storage.NewMemoryStore()
+	store.RecordFollowerSamples([]storage.FollowerSample{
+		{UserID: "testchannel", Followers: 800, RecordedAt: now.AddDate(0, 0, -30).Add(-12 * time.Hour)},
+		{UserID: "testchannel", Followers: 1000, RecordedAt: now.AddDate(0, 0, -7).Add(-12 * time.Hour)},
+		{UserID: "testchannel", Followers: 1150, RecordedAt: now.AddDate(0, 0, -2)},
+	})
+
+	response := followersRequest(&MockFollowersService{total: 1200}, store)
+
+	var body Followers
+	json.NewDecoder(response.Body).Decode(&body)
+	samples, _ := store.FollowerSamples("testchannel", time.Time{}, time.Time{})
+
+	if !(response.Code == 200 && body.Total == 1200 && len(body.Growth) == 3 &&
+		body.Growth[0].Days == 7 && *body.Growth[0].Change == 200 && *body.Growth[0].Baseline == 1000 && floatCompare(*body.Growth[0].Percent, 20) &&
+		body.Growth[1].Days == 30 && *body.Growth[1].Change == 400 && floatCompare(*body.Growth[1].Percent, 50) &&
+		body.Growth[2].Days == 90 && body.Growth[2].Change == nil && body.Growth[2].Since.IsZero() &&
+		len(samples) == 4 && samples[3].Followers == 1200) {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v | Growth %+v`, response.Code, body, body.Growth)
+	}
+}
+
+func TestRouteFollowersSampleInterval(t *testing.T) {
+	store := storage.NewMemoryStore()
+	store.RecordFollowerSamples([]storage.FollowerSample{{UserID: "testchannel", Followers: 40, RecordedAt: time.Now().UTC().Add(-10 * time.Minute)}})
+
+	followersRequest(&MockFollowersService{total: 50}, store)
+	followersRequest(&MockFollowersService{total: 60}, store)
+	samples, _ := store.FollowerSamples("testchannel", time.Time{}, time.Time{})
+
+	if !(len(samples) == 1 && samples[0].Followers == 40) {
+		t.Errorf(`TestRouteFollowersSampleInterval failed - samples: %+v`, samples)
+	}
+}
+
+func TestRouteFollowersNoHistory(t *testing.T) {
+	response := followersRequest(&MockFollowersService{total: 50}, storage.NewMemoryStore())
+
+	var body map[string]any
+	json.NewDecoder(response.Body).Decode(&body)
+	growth, _ := body["growth"].([]any)
+
+	if !(response.Code == 200 && body["total"] == 50.0 && len(growth) == 3 && growth[0].(map[string]any)["change"] == nil) {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
+
+func TestRouteFollowersTwitchError(t *testing.T) {
+	store := storage.NewMemoryStore()
+	response := followersRequest(&MockFollowersService{err: &twitch.UnauthorizedError{}}, store)
+	samples, _ := store.FollowerSamples("testchannel", time.Time{}, time.Time{})
+
+	if !(response.Code == 502 && len(samples) == 0) {
+		t.Errorf(`Route test failed - Status %d (expected 502) | Samples %+v`, response.Code, samples)
+	}
+}
+
+func TestGenerateFollowerGrowthZeroBaseline(t *testing.T) {
+	now := at("2024-06-01T00:00:00Z")
+	growth := generateFollowerGrowth(10, []storage.FollowerSample{{Followers: 0, RecordedAt: at("2024-05-24T12:00:00Z")}}, now)
+
+	if !(growth[0].Change != nil && *growth[0].Change == 10 && growth[0].Percent == nil && growth[2].Change == nil) {
+		t.Errorf(`TestGenerateFollowerGrowthZeroBaseline failed - growth: %+v`, growth)
+	}
+}
+
+func TestGenerateFollowerGrowthStaleBaseline(t *testing.T) {
+	now := at("2024-06-01T00:00:00Z")
+	growth := generateFollowerGrowth(500, []storage.FollowerSample{{Followers: 100, RecordedAt: now.AddDate(0, 0, -60)}}, now)
+
+	// The only sample is far older than the 7 and 30 day cutoffs and newer than the 90 day one
+	for _, window := range growth {
+		if !(window.Baseline == nil && window.Change == nil && window.Percent == nil && window.Since.IsZero()) {
+			t.Errorf(`TestGenerateFollowerGrowthStaleBaseline failed - growth: %+v`, growth)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package routes
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+	// Embedded so the tz parameter works in containers without zoneinfo
+	_ "time/tzdata"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+var HeatmapDays = [7]string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+type HeatmapSlot struct {
+	HoursStreamed float64 `json:"hoursStreamed"`
+	Broadcasts    int     `json:"broadcasts"`
+	AverageViews  float64 `json:"averageViews"`
+}
+
+// Slots are indexed by day (Monday first, matching Days) and then by hour
+type Heatmap struct {
+	Timezone string             `json:"timezone"`
+	Days     [7]string          `json:"days"`
+	Slots    [7][24]HeatmapSlot `json:"slots"`
+}
+
+func RouteGetStreamerHeatmap(c *gin.Context, log slog.Logger, twitch ITwitch) {
+
+	input := parseInput(c)
+
+	location, err := time.LoadLocation(c.DefaultQuery("tz", "UTC"))
+	if err != nil {
+		input.errors = append(input.errors, "Invalid tz parameter")
+	}
+
+	if len(input.errors) > 0 {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, input.errors...)
+		return
+	}
+
+	result, ok := fetchVideos(c, twitch, input)
+	if !ok {
+		return
+	}
+
+	heatmap := generateHeatmap(result, location)
+
+	log.Debug("Returning heatmap", "timezone", heatmap.Timezone, "videos", len(result))
+
+	c.JSON(http.StatusOK, heatmap)
+}
+
+// generateHeatmap spreads each video across the weekday/hour slots it was
+// live in. Hours streamed are split exactly at the hour boundaries, while a
+// video's views count in full towards the average of every slot it touched.
+func generateHeatmap(videos []twitch.Video, location *time.Location) Heatmap {
+	heatmap := Heatmap{Timezone: location.String(), Days: HeatmapDays}
+	var totalViews [7][24]int
+
+	for _, video := range videos {
+		start := video.CreatedAt.In(location)
+		end := start.Add(video.Duration)
+
+		slot := time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), 0, 0, 0, location)
+		for cursor := start; ; {
+			day, hour := heatmapDay(slot), slot.Hour()
+			next := slot.Add(time.Hour)
+			until := next
+			if end.Before(next) {
+				until = end
+			}
+
+			heatmap.Slots[day][hour].HoursStreamed += until.Sub(cursor).Hours()
+			heatmap.Slots[day][hour].Broadcasts++
+			totalViews[day][hour] += video.Views
+
+			if !next.Before(end) {
+				break
+			}
+			cursor, slot = next, next
+		}
+	}
+
+	for day := range heatmap.Slots {
+		for hour := range heatmap.Slots[day] {
+			if broadcasts := heatmap.Slots[day][hour].Broadcasts; broadcasts > 0 {
+				heatmap.Slots[day][hour].AverageViews = float64(totalViews[day][hour]) / float64(broadcasts)
+			}
+		}
+	}
+	return heatmap
+}
+
+func heatmapDay(t time.Time) int {
+	return (int(t.Weekday()) + 6) % 7
+}
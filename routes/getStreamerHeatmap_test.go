@@ -0,0 +1,116 @@
+package routes
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+func compareSlot(t *testing.T, heatmap Heatmap, day int, hour int, expected HeatmapSlot) {
+	reality := heatmap.Slots[day][hour]
+	if !(floatCompare(reality.HoursStreamed, expected.HoursStreamed) &&
+		reality.Broadcasts == expected.Broadcasts &&
+		floatCompare(reality.AverageViews, expected.AverageViews)) {
+		t.Errorf(`generateHeatmap slot %s %02d:00 should be %+v but is %+v`, HeatmapDays[day], hour, expected, reality)
+	}
+}
+
+func countSlots(heatmap Heatmap) int {
+	n := 0
+	for day := range heatmap.Slots {
+		for hour := range heatmap.Slots[day] {
+			if heatmap.Slots[day][hour].Broadcasts > 0 {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func TestGenerateHeatmapSplitsHours(t *testing.T) {
+	// Wednesday 18:30 for 2 hours
+	videos := []twitch.Video{{Views: 300, Duration: duration("2h"), CreatedAt: at("2024-04-03T18:30:00Z")}}
+	heatmap := generateHeatmap(videos, time.UTC)
+
+	compareSlot(t, heatmap, 2, 18, HeatmapSlot{HoursStreamed: 0.5, Broadcasts: 1, AverageViews: 300})
+	compareSlot(t, heatmap, 2, 19, HeatmapSlot{HoursStreamed: 1, Broadcasts: 1, AverageViews: 300})
+	compareSlot(t, heatmap, 2, 20, HeatmapSlot{HoursStreamed: 0.5, Broadcasts: 1, AverageViews: 300})
+	if n := countSlots(heatmap); n != 3 {
+		t.Errorf(`generateHeatmap should fill 3 slots but fills %d`, n)
+	}
+}
+
+func TestGenerateHeatmapWrapsWeek(t *testing.T) {
+	// Sunday 23:30 into Monday
+	videos := []twitch.Video{{Views: 100, Duration: duration("1h"), CreatedAt: at("2024-04-07T23:30:00Z")}}
+	heatmap := generateHeatmap(videos, time.UTC)
+
+	compareSlot(t, heatmap, 6, 23, HeatmapSlot{HoursStreamed: 0.5, Broadcasts: 1, AverageViews: 100})
+	compareSlot(t, heatmap, 0, 0, HeatmapSlot{HoursStreamed: 0.5, Broadcasts: 1, AverageViews: 100})
+}
+
+func TestGenerateHeatmapAveragesViews(t *testing.T) {
+	videos := []twitch.Video{
+		{Views: 100, Duration: duration("1h"), CreatedAt: at("2024-04-01T10:00:00Z")},
+		{Views: 300, Duration: duration("30m"), CreatedAt: at("2024-04-08T10:15:00Z")},
+		{Views: 50, Duration: duration("0s"), CreatedAt: at("2024-04-15T10:59:00Z")},
+	}
+	heatmap := generateHeatmap(videos, time.UTC)
+
+	compareSlot(t, heatmap, 0, 10, HeatmapSlot{HoursStreamed: 1.5, Broadcasts: 3, AverageViews: 150})
+	if n := countSlots(heatmap); n != 1 {
+		t.Errorf(`generateHeatmap should fill 1 slot but fills %d`, n)
+	}
+}
+
+func TestGenerateHeatmapTimezone(t *testing.T) {
+	location, _ := time.LoadLocation("America/New_York")
+	// Tuesday 02:00 UTC is Monday 22:00 in New York during daylight saving
+	videos := []twitch.Video{{Views: 100, Duration: duration("1h"), CreatedAt: at("2024-04-02T02:00:00Z")}}
+	heatmap := generateHeatmap(videos, location)
+
+	compareSlot(t, heatmap, 0, 22, HeatmapSlot{HoursStreamed: 1, Broadcasts: 1, AverageViews: 100})
+	if heatmap.Timezone != "America/New_York" {
+		t.Errorf(`generateHeatmap timezone should be America/New_York but is %s`, heatmap.Timezone)
+	}
+}
+
+func TestRouteHeatmapInvalidTimezone(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats/heatmap?limit=10&tz=Mars/Olympus_Mons", nil)
+
+	service := mockService([]twitch.Video{}, nil)
+
+	RouteGetStreamerHeatmap(c, *slog.Default(), &service)
+
+	err := errResponse(response)
+
+	if !(response.Code == 400 && len(err.Errors) == 1 && len(service.stack) == 0) {
+		t.Errorf(`Route test failed - Status %d (expected 400) | Body %v`, response.Code, err)
+	}
+}
+
+func TestRouteHeatmapSuccess(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats/heatmap?limit=10&tz=Europe/London", nil)
+
+	service := mockService([]twitch.Video{{Views: 100, Duration: duration("1h"), CreatedAt: at("2024-04-01T10:00:00Z")}}, nil)
+
+	RouteGetStreamerHeatmap(c, *slog.Default(), &service)
+
+	var body Heatmap
+	json.NewDecoder(response.Body).Decode(&body)
+
+	if !(response.Code == 200 && body.Timezone == "Europe/London" && body.Slots[0][11].Broadcasts == 1) {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
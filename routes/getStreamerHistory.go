@@ -0,0 +1,172 @@
+package routes
+
+import (
+	"cmp"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+)
+
+const DefaultHistoryWindow = 7 * 24 * time.Hour
+
+type ISnapshotStore interface {
+	Snapshots(string, time.Time, time.Time) ([]storage.Snapshot, error)
+}
+
+type VideoViewDelta struct {
+	ID             string    `json:"id"`
+	Title          string    `json:"title"`
+	FromViews      int       `json:"fromViews"`
+	ToViews        int       `json:"toViews"`
+	Delta          int       `json:"delta"`
+	FromRecordedAt time.Time `json:"fromRecordedAt"`
+	ToRecordedAt   time.Time `json:"toRecordedAt"`
+}
+
+type History struct {
+	From       time.Time        `json:"from"`
+	To         time.Time        `json:"to"`
+	TotalDelta int              `json:"totalDelta"`
+	Videos     []VideoViewDelta `json:"videos"`
+}
+
+func RouteGetStreamerHistory(c *gin.Context, log slog.Logger, twitch ITwitch, store ISnapshotStore) {
+
+	channelId := c.Param("channelId")
+
+	errors := []string{}
+	if len(channelId) == 0 {
+		errors = append(errors, "Missing channel ID")
+	}
+
+	from, to, rangeErrors := parseRange(c)
+	errors = append(errors, rangeErrors...)
+
+	if len(errors) > 0 {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, errors...)
+		return
+	}
+
+	userId, ok := resolveUserId(c, twitch, channelId)
+	if !ok {
+		return
+	}
+
+	// Everything up to the end of the range, as the baseline for a video may
+	// have been recorded before the range starts
+	snapshots, err := store.Snapshots(userId, time.Time{}, to)
+	if err != nil {
+		log.Error("Failed to read snapshots", "userId", userId, "err", err)
+		respondError(c, http.StatusInternalServerError, CodeInternal, MessageUnknown)
+		return
+	}
+
+	history := generateHistory(snapshots, from, to)
+
+	log.Debug("Returning view history", "userId", userId, "snapshots", len(snapshots), "videos", len(history.Videos))
+
+	c.JSON(http.StatusOK, history)
+}
+
+// parseRange reads the from and to parameters, by default covering the last
+// DefaultHistoryWindow
+func parseRange(c *gin.Context) (from time.Time, to time.Time, errors []string) {
+	to, ok := parseEndTime(c.Query("to"), time.Now().UTC())
+	if !ok {
+		errors = append(errors, "Invalid to parameter")
+	}
+	from, ok = parseTime(c.Query("from"), to.Add(-DefaultHistoryWindow))
+	if !ok {
+		errors = append(errors, "Invalid from parameter")
+	}
+	if len(errors) == 0 && !from.Before(to) {
+		errors = append(errors, "from must be before to")
+	}
+	return from, to, errors
+}
+
+// parseTime accepts either an RFC 3339 timestamp or a plain date, which is
+// taken as midnight UTC
+func parseTime(value string, fallback time.Time) (time.Time, bool) {
+	if value == "" {
+		return fallback, true
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed.UTC(), true
+	}
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return parsed, true
+	}
+	return fallback, false
+}
+
+// parseEndTime is parseTime for the end of a range, where a plain date covers
+// the whole of that day. Ranges include their end, so it stops just short of
+// the following midnight.
+func parseEndTime(value string, fallback time.Time) (time.Time, bool) {
+	if parsed, err := time.Parse(time.DateOnly, value); err == nil {
+		return parsed.Add(24*time.Hour - time.Nanosecond), true
+	}
+	return parseTime(value, fallback)
+}
+
+// generateHistory reports on each video with a snapshot inside the range. A
+// video's views are measured from the last snapshot at or before from, or its
+// first snapshot in the range if there isn't one, to its last in the range.
+// Snapshots must be oldest first.
+func generateHistory(snapshots []storage.Snapshot, from time.Time, to time.Time) History {
+	type span struct {
+		first, last *storage.Snapshot
+		inRange     bool
+	}
+	spans := map[string]*span{}
+
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		if snapshot.RecordedAt.After(to) {
+			continue
+		}
+		s, ok := spans[snapshot.VideoID]
+		if !ok {
+			s = &span{}
+			spans[snapshot.VideoID] = s
+		}
+		if !snapshot.RecordedAt.After(from) {
+			s.first = snapshot
+			s.inRange = snapshot.RecordedAt.Equal(from)
+		} else {
+			if s.first == nil {
+				s.first = snapshot
+			}
+			s.inRange = true
+		}
+		s.last = snapshot
+	}
+
+	history := History{From: from, To: to, Videos: []VideoViewDelta{}}
+	for id, s := range spans {
+		if !s.inRange {
+			continue
+		}
+		delta := VideoViewDelta{
+			ID:             id,
+			Title:          s.last.Title,
+			FromViews:      s.first.Views,
+			ToViews:        s.last.Views,
+			Delta:          s.last.Views - s.first.Views,
+			FromRecordedAt: s.first.RecordedAt,
+			ToRecordedAt:   s.last.RecordedAt,
+		}
+		history.TotalDelta += delta.Delta
+		history.Videos = append(history.Videos, delta)
+	}
+
+	slices.SortFunc(history.Videos, func(a, b VideoViewDelta) int {
+		return cmp.Or(cmp.Compare(b.Delta, a.Delta), cmp.Compare(a.ID, b.ID))
+	})
+	return history
+}
@@ -0,0 +1,183 @@
+package routes
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+func snapshot(videoId string, views int, recordedAt string) storage.Snapshot {
+	return storage.Snapshot{VideoID: videoId, UserID: "testchannel", Title: "Video " + videoId, Views: views, RecordedAt: at(recordedAt)}
+}
+
+func historySnapshots() []storage.Snapshot {
+	return []storage.Snapshot{
+		snapshot("1", 100, "2024-04-01T00:00:00Z"),
+		snapshot("2", 50, "2024-04-01T00:00:00Z"),
+		snapshot("1", 150, "2024-04-03T00:00:00Z"),
+		snapshot("3", 10, "2024-04-04T00:00:00Z"),
+		snapshot("1", 400, "2024-04-06T00:00:00Z"),
+		snapshot("3", 30, "2024-04-06T00:00:00Z"),
+		snapshot("1", 900, "2024-04-09T00:00:00Z"),
+	}
+}
+
+func TestGenerateHistory(t *testing.T) {
+	history := generateHistory(historySnapshots(), at("2024-04-02T00:00:00Z"), at("2024-04-07T00:00:00Z"))
+
+	// Video 1 is measured from its snapshot before the range, video 2 has none in it
+	if !(history.TotalDelta == 320 && len(history.Videos) == 2 &&
+		history.Videos[0].ID == "1" && history.Videos[0].FromViews == 100 && history.Videos[0].ToViews == 400 &&
+		history.Videos[0].Delta == 300 && history.Videos[0].FromRecordedAt.Equal(at("2024-04-01T00:00:00Z")) &&
+		history.Videos[1].ID == "3" && history.Videos[1].FromViews == 10 && history.Videos[1].Delta == 20) {
+		t.Errorf(`TestGenerateHistory failed - history: %+v`, history)
+	}
+}
+
+func TestGenerateHistoryEmpty(t *testing.T) {
+	history := generateHistory(historySnapshots(), at("2024-05-01T00:00:00Z"), at("2024-05-07T00:00:00Z"))
+
+	if !(history.TotalDelta == 0 && history.Videos != nil && len(history.Videos) == 0) {
+		t.Errorf(`TestGenerateHistoryEmpty failed - history: %+v`, history)
+	}
+}
+
+func TestParseEndTime(t *testing.T) {
+	fallback := at("2024-01-01T00:00:00Z")
+	tests := []struct {
+		value    string
+		expected time.Time
+		ok       bool
+	}{
+		{"", fallback, true},
+		{"2024-05-01", at("2024-05-02T00:00:00Z").Add(-time.Nanosecond), true},
+		{"2024-05-01T12:00:00Z", at("2024-05-01T12:00:00Z"), true},
+		{"tomorrow", fallback, false},
+	}
+
+	for _, test := range tests {
+		if result, ok := parseEndTime(test.value, fallback); !(result.Equal(test.expected) && ok == test.ok) {
+			t.Errorf(`parseEndTime(%q) should return %s, %t but returns %s, %t`, test.value, test.expected, test.ok, result, ok)
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	fallback := at("2024-01-01T00:00:00Z")
+	tests := []struct {
+		value    string
+		expected time.Time
+		ok       bool
+	}{
+		{"", fallback, true},
+		{"2024-04-03", at("2024-04-03T00:00:00Z"), true},
+		{"2024-04-03T12:00:00+02:00", at("2024-04-03T10:00:00Z"), true},
+		{"yesterday", fallback, false},
+	}
+
+	for _, test := range tests {
+		if result, ok := parseTime(test.value, fallback); !(result.Equal(test.expected) && ok == test.ok) {
+			t.Errorf(`parseTime(%q) should return %s, %t but returns %s, %t`, test.value, test.expected, test.ok, result, ok)
+		}
+	}
+}
+
+func historyRequest(query string) (*httptest.ResponseRecorder, *gin.Context) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/history"+query, nil)
+	return response, c
+}
+
+func TestRouteHistorySuccess(t *testing.T) {
+	response, c := historyRequest("?from=2024-04-02&to=2024-04-10")
+	service := mockService([]twitch.Video{}, nil)
+	store := storage.NewMemoryStore()
+	store.RecordSnapshots(historySnapshots())
+
+	RouteGetStreamerHistory(c, *slog.Default(), &service, store)
+
+	var body History
+	json.NewDecoder(response.Body).Decode(&body)
+
+	if !(response.Code == 200 && body.TotalDelta == 820 && len(body.Videos) == 2 && body.Videos[0].ToViews == 900 &&
+		body.From.Equal(at("2024-04-02T00:00:00Z")) && service.resolved[0] == "testchannel") {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
+
+func TestRouteHistoryIncludesEndDate(t *testing.T) {
+	response, c := historyRequest("?from=2024-04-02&to=2024-04-09")
+	service := mockService([]twitch.Video{}, nil)
+	store := storage.NewMemoryStore()
+	store.RecordSnapshots(append(historySnapshots(),
+		snapshot("1", 950, "2024-04-09T18:00:00Z"),
+		snapshot("1", 999, "2024-04-10T00:00:00Z"),
+	))
+
+	RouteGetStreamerHistory(c, *slog.Default(), &service, store)
+
+	var body History
+	json.NewDecoder(response.Body).Decode(&body)
+
+	if !(response.Code == 200 && body.Videos[0].ID == "1" && body.Videos[0].ToViews == 950 &&
+		body.To.Equal(at("2024-04-10T00:00:00Z").Add(-time.Nanosecond))) {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
+
+func TestRouteHistorySameDay(t *testing.T) {
+	response, c := historyRequest("?from=2024-04-06&to=2024-04-06")
+	service := mockService([]twitch.Video{}, nil)
+
+	RouteGetStreamerHistory(c, *slog.Default(), &service, storage.NewMemoryStore())
+
+	if response.Code != 200 {
+		t.Errorf(`Route test failed - Status %d (expected 200)`, response.Code)
+	}
+}
+
+func TestRouteHistoryInvalidRange(t *testing.T) {
+	response, c := historyRequest("?from=2024-04-10&to=2024-04-02")
+	service := mockService([]twitch.Video{}, nil)
+
+	RouteGetStreamerHistory(c, *slog.Default(), &service, storage.NewMemoryStore())
+
+	err := errResponse(response)
+
+	if !(response.Code == 400 && len(err.Errors) == 1 && len(service.resolved) == 0) {
+		t.Errorf(`Route test failed - Status %d (expected 400) | Body %v`, response.Code, err)
+	}
+}
+
+func TestRouteHistoryInvalidDates(t *testing.T) {
+	response, c := historyRequest("?from=soon&to=later")
+	service := mockService([]twitch.Video{}, nil)
+
+	RouteGetStreamerHistory(c, *slog.Default(), &service, storage.NewMemoryStore())
+
+	err := errResponse(response)
+
+	if !(response.Code == 400 && len(err.Errors) == 2) {
+		t.Errorf(`Route test failed - Status %d (expected 400) | Body %v`, response.Code, err)
+	}
+}
+
+func TestRouteHistoryUnknownUser(t *testing.T) {
+	response, c := historyRequest("")
+	service := mockService([]twitch.Video{}, nil)
+	service.unknown = true
+
+	RouteGetStreamerHistory(c, *slog.Default(), &service, storage.NewMemoryStore())
+
+	if response.Code != 404 {
+		t.Errorf(`Route test failed - Status %d (expected 404)`, response.Code)
+	}
+}
@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+type ILiveTwitch interface {
+	IUserResolver
+	GetStreams(context.Context, []string) ([]twitch.Stream, error)
+}
+
+type IViewerSamples interface {
+	ViewerSamples(string, time.Time, time.Time) ([]storage.ViewerSample, error)
+}
+
+type LiveStatus struct {
+	Live        bool      `json:"live"`
+	StreamID    string    `json:"streamId,omitempty"`
+	ViewerCount int       `json:"viewerCount"`
+	GameID      string    `json:"gameId,omitempty"`
+	GameName    string    `json:"gameName,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	StartedAt   time.Time `json:"startedAt,omitzero"`
+	// Uptime is in seconds
+	Uptime int `json:"uptime"`
+	// Viewers summarises the samples taken of this broadcast, if the
+	// channel is being sampled
+	Viewers *BroadcastViewers `json:"viewers,omitempty"`
+}
+
+func RouteGetStreamerLive(c *gin.Context, log slog.Logger, service ILiveTwitch, store IViewerSamples) {
+
+	channelId := c.Param("channelId")
+	if len(channelId) == 0 {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Missing channel ID")
+		return
+	}
+
+	userId, ok := resolveUserId(c, service, channelId)
+	if !ok {
+		return
+	}
+
+	streams, err := service.GetStreams(requestContext(c), []string{userId})
+	if err != nil {
+		respondTwitchError(c, err)
+		return
+	}
+	if len(streams) == 0 {
+		c.JSON(http.StatusOK, LiveStatus{Live: false})
+		return
+	}
+
+	stream := streams[0]
+	status := LiveStatus{
+		Live:        true,
+		StreamID:    stream.ID,
+		ViewerCount: stream.ViewerCount,
+		GameID:      stream.GameID,
+		GameName:    stream.GameName,
+		Title:       stream.Title,
+		StartedAt:   stream.StartedAt,
+		Uptime:      int(time.Since(stream.StartedAt).Seconds()),
+	}
+
+	samples, err := store.ViewerSamples(userId, stream.StartedAt, time.Time{})
+	if err != nil {
+		// The live status is still worth returning without the samples
+		log.Error("Failed to read viewer samples", "userId", userId, "err", err)
+	}
+	for _, broadcast := range summariseBroadcasts(samples) {
+		if broadcast.StreamID == stream.ID {
+			status.Viewers = &broadcast
+		}
+	}
+
+	log.Debug("Returning live status", "userId", userId, "streamId", stream.ID, "viewers", stream.ViewerCount)
+
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+type MockLiveService struct {
+	requested [][]string
+	streams   []twitch.Stream
+	err       error
+}
+
+func (m *MockLiveService) ResolveUserId(ctx context.Context, channel string) (string, error) {
+	return channel, nil
+}
+
+func (m *MockLiveService) GetStreams(ctx context.Context, userIds []string) ([]twitch.Stream, error) {
+	m.requested = append(m.requested, userIds)
+	return m.streams, m.err
+}
+
+func liveRequest(service *MockLiveService, store IViewerSamples) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/live", nil)
+
+	RouteGetStreamerLive(c, *slog.Default(), service, store)
+	return response
+}
+
+func TestRouteLive(t *testing.T) {
+	startedAt := time.Now().Add(-90 * time.Minute).UTC()
+	service := &MockLiveService{streams: []twitch.Stream{
+		{ID: "s1", UserID: "testchannel", GameID: "743", GameName: "Chess", Title: "Blitz", ViewerCount: 250, StartedAt: startedAt},
+	}}
+	store := storage.NewMemoryStore()
+	store.RecordViewerSamples([]storage.ViewerSample{
+		{UserID: "testchannel", StreamID: "old", ViewerCount: 999, StartedAt: startedAt.Add(-48 * time.Hour), RecordedAt: startedAt.Add(-47 * time.Hour)},
+		{UserID: "testchannel", StreamID: "s1", ViewerCount: 100, StartedAt: startedAt, RecordedAt: startedAt.Add(time.Minute)},
+		{UserID: "testchannel", StreamID: "s1", ViewerCount: 300, StartedAt: startedAt, RecordedAt: startedAt.Add(time.Hour)},
+	})
+
+	response := liveRequest(service, store)
+
+	var body LiveStatus
+	json.NewDecoder(response.Body).Decode(&body)
+
+	if !(response.Code == 200 && body.Live && body.StreamID == "s1" && body.ViewerCount == 250 && body.GameName == "Chess" &&
+		body.Uptime >= 5400 && body.Uptime < 5410 && service.requested[0][0] == "testchannel" &&
+		body.Viewers != nil && body.Viewers.Samples == 2 && body.Viewers.PeakViewers == 300 && floatCompare(body.Viewers.AverageViewers, 200)) {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v | Viewers %+v`, response.Code, body, body.Viewers)
+	}
+}
+
+func TestRouteLiveOffline(t *testing.T) {
+	response := liveRequest(&MockLiveService{streams: []twitch.Stream{}}, storage.NewMemoryStore())
+
+	var body map[string]any
+	json.NewDecoder(response.Body).Decode(&body)
+
+	_, hasStartedAt := body["startedAt"]
+	if !(response.Code == 200 && body["live"] == false && !hasStartedAt && body["viewers"] == nil) {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
+
+func TestRouteLiveTwitchError(t *testing.T) {
+	response := liveRequest(&MockLiveService{err: &twitch.RateLimitedError{RetryAfter: 3 * time.Second}}, storage.NewMemoryStore())
+
+	if !(response.Code == 503 && response.Header().Get("Retry-After") == "3") {
+		t.Errorf(`Route test failed - Status %d (expected 503)`, response.Code)
+	}
+}
@@ -1,27 +1,55 @@
 package routes
 
 import (
+	"context"
 	"log/slog"
 	"net/http"
+	"regexp"
+	"slices"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
 )
 
+type IUserResolver interface {
+	ResolveUserId(context.Context, string) (string, error)
+}
+
 type ITwitch interface {
-	GetUserVideos(string, int) ([]twitch.Video, error)
+	IUserResolver
+	GetUserVideos(context.Context, string, int, twitch.VideoFilter) ([]twitch.Video, error)
+}
+
+// IStatsTwitch is what the stats route needs, which is more than the other
+// aggregating routes as it can break the videos down by game
+type IStatsTwitch interface {
+	ITwitch
+	IGames
 }
 
 type parsedInput struct {
 	channelId string
 	limit     int
-	errors    []string
+	filter    twitch.VideoFilter
+	// partial accepts the videos fetched before pagination failed
+	partial bool
+	errors  []string
 }
 
+// Helix takes ISO 639-1 codes, or "other" for anything it doesn't recognise
+var languagePattern = regexp.MustCompile(`^([a-z]{2}|other)$`)
+
+const (
+	MessageNoUser   = "No user found for this channel"
+	MessageNoVideos = "No videos found for this user"
+	MessageUnknown  = "Something went wrong"
+)
+
 type ErrorResponseBody struct {
 	Errors []string `json:"errors"`
+	Code   string   `json:"code,omitempty"`
 }
 
 type SimpleVideo struct {
@@ -30,35 +58,64 @@ type SimpleVideo struct {
 }
 
 type Stats struct {
-	TotalViews      int         `json:"totalViews"`
-	MeanViews       int         `json:"meanViews"`
-	TotalLength     int         `json:"totalLength"`
-	ViewsPerMinute  float64     `json:"viewsPerMinute"`
-	MostViewedVideo SimpleVideo `json:"mostViewedVideo"`
+	TotalViews      int             `json:"totalViews"`
+	MeanViews       int             `json:"meanViews"`
+	TotalLength     int             `json:"totalLength"`
+	ViewsPerMinute  float64         `json:"viewsPerMinute"`
+	MostViewedVideo SimpleVideo     `json:"mostViewedVideo"`
+	Views           Distribution    `json:"views"`
+	Duration        Distribution    `json:"duration"`
+	TopVideos       []RankedVideo   `json:"topVideos,omitempty"`
+	BottomVideos    []RankedVideo   `json:"bottomVideos,omitempty"`
+	VideoCount      int             `json:"videoCount"`
+	Partial         bool            `json:"partial,omitempty"`
+	PartialError    string          `json:"partialError,omitempty"`
+	PartialCode     string          `json:"partialCode,omitempty"`
+	Categories      []CategoryStats `json:"categories,omitempty"`
 }
 
-func RouteGetStreamerStats(c *gin.Context, log slog.Logger, twitch ITwitch) {
+func RouteGetStreamerStats(c *gin.Context, log slog.Logger, service IStatsTwitch, store IViewerSamples) {
 
 	input := parseInput(c)
+	ranking := parseRanking(c)
+	input.errors = append(input.errors, ranking.errors...)
 
-	if len(input.errors) > 0 {
-		c.JSON(http.StatusBadRequest, ErrorResponseBody{Errors: input.errors})
-		return
-	}
-
-	result, err := twitch.GetUserVideos(input.channelId, input.limit)
+	input.partial = parseFlag(c, "partial", &input.errors)
+	categorise := parseFlag(c, "categories", &input.errors)
 
-	if err != nil {
-		c.JSON(500, ErrorResponseBody{Errors: []string{"Something went wrong"}})
+	if len(input.errors) > 0 {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, input.errors...)
 		return
 	}
 
-	if len(result) == 0 {
-		c.JSON(404, ErrorResponseBody{Errors: []string{"No videos found for this userId"}})
+	result, partialErr, ok := fetchPartialVideos(c, service, input)
+	if !ok {
 		return
 	}
 
 	stats := generateStats(result)
+	if partialErr != nil {
+		response := classifyError(partialErr)
+		stats.Partial = true
+		stats.PartialError = response.message
+		stats.PartialCode = response.code
+		log.Warn("Returning stats over partial results", "videos", len(result), "limit", input.limit, "err", partialErr)
+	}
+	if ranking.top > 0 {
+		stats.TopVideos = topVideos(result, ranking.top, ranking.by)
+	}
+	if ranking.bottom > 0 {
+		stats.BottomVideos = bottomVideos(result, ranking.bottom, ranking.by)
+	}
+	if categorise {
+		var categories map[string]string
+		stats.Categories, categories, ok = fetchCategories(c, log, service, store, result)
+		if !ok {
+			return
+		}
+		categoriseRanked(stats.TopVideos, categories, stats.Categories)
+		categoriseRanked(stats.BottomVideos, categories, stats.Categories)
+	}
 
 	log.Debug("Returning stats blob", "stats", stats)
 
@@ -66,20 +123,105 @@ func RouteGetStreamerStats(c *gin.Context, log slog.Logger, twitch ITwitch) {
 
 }
 
+// fetchVideos resolves the channel and fetches its videos, writing the error
+// response itself if either fails or there are no videos to report on
+func fetchVideos(c *gin.Context, service ITwitch, input parsedInput) ([]twitch.Video, bool) {
+	result, _, ok := fetchPartialVideos(c, service, input)
+	return result, ok
+}
+
+// fetchPartialVideos is fetchVideos, except that when the input allows partial
+// results and pagination fails after some videos arrived, those videos are
+// returned along with the error instead of failing the request
+func fetchPartialVideos(c *gin.Context, service ITwitch, input parsedInput) (result []twitch.Video, partialErr error, ok bool) {
+	userId, ok := resolveUserId(c, service, input.channelId)
+	if !ok {
+		return nil, nil, false
+	}
+
+	result, err := service.GetUserVideos(requestContext(c), userId, input.limit, input.filter)
+
+	if err != nil && input.partial && len(result) > 0 {
+		return result, err, true
+	}
+	if err != nil {
+		respondTwitchError(c, err)
+		return nil, nil, false
+	}
+
+	if len(result) == 0 {
+		respondError(c, http.StatusNotFound, CodeNoVideos, MessageNoVideos)
+		return nil, nil, false
+	}
+	return result, nil, true
+}
+
+// requestContext carries a client's Cache-Control: no-cache through to the
+// twitch service so it fetches fresh video pages
+func requestContext(c *gin.Context) context.Context {
+	ctx := c.Request.Context()
+	if strings.Contains(strings.ToLower(c.GetHeader("Cache-Control")), "no-cache") {
+		return twitch.WithoutCache(ctx)
+	}
+	return ctx
+}
+
+// resolveUserId writes the error response itself when the channel can't be
+// resolved, so callers only need to bail out when ok is false
+func resolveUserId(c *gin.Context, service IUserResolver, channel string) (userId string, ok bool) {
+	userId, err := service.ResolveUserId(requestContext(c), channel)
+	if err != nil {
+		respondTwitchError(c, err)
+		return "", false
+	}
+	return userId, true
+}
+
 func parseInput(c *gin.Context) parsedInput {
 	channelId := c.Param("channelId")
-	limit, err := strconv.Atoi(c.Query("limit"))
 
 	errors := []string{}
 	if len(channelId) == 0 {
 		// This state probably shouldn't be possible for the current route definition
 		errors = append(errors, "Missing channel ID")
 	}
-	if err != nil || limit == 0 {
+
+	input := parseQuery(c)
+	input.channelId = channelId
+	input.errors = append(errors, input.errors...)
+	return input
+}
+
+// parseQuery parses the query parameters shared by every route that
+// aggregates over a streamer's videos
+func parseQuery(c *gin.Context) parsedInput {
+	limit, err := strconv.Atoi(c.Query("limit"))
+
+	errors := []string{}
+	if err != nil || limit <= 0 {
 		errors = append(errors, "Missing or invalid limit parameter")
 	}
 
-	return parsedInput{channelId, limit, errors}
+	filter := twitch.VideoFilter{
+		Type:     c.Query("type"),
+		Period:   c.Query("period"),
+		Sort:     c.Query("sort"),
+		Language: c.Query("language"),
+	}
+	if filter.Type != "" && !slices.Contains(twitch.VideoTypes, filter.Type) {
+		errors = append(errors, "Invalid type parameter")
+	}
+	if filter.Period != "" && !slices.Contains(twitch.VideoPeriods, filter.Period) {
+		errors = append(errors, "Invalid period parameter")
+	}
+	if filter.Sort != "" && !slices.Contains(twitch.VideoSorts, filter.Sort) {
+		errors = append(errors, "Invalid sort parameter")
+	}
+	if filter.Language != "" && !languagePattern.MatchString(filter.Language) {
+		errors = append(errors, "Invalid language parameter")
+	}
+
+	return parsedInput{limit: limit, filter: filter, errors: errors}
 }
 
 func generateStats(videos []twitch.Video) Stats {
@@ -91,24 +233,32 @@ func generateStats(videos []twitch.Video) Stats {
 		totalViews  int     = 0
 		totalLength float64 = 0
 	)
-	mostViewed := SimpleVideo{Title: "", Views: 0}
+	views := make([]float64, 0, len(videos))
+	durations := make([]float64, 0, len(videos))
 	for _, video := range videos {
 		totalViews = totalViews + video.Views
-		duration, e := time.ParseDuration(video.Duration)
-		if e == nil {
-			// TODO: should handle any errors in this parsing
-			totalLength = totalLength + duration.Seconds()
-		}
-		if video.Views > mostViewed.Views {
-			mostViewed = SimpleVideo{Title: video.Title, Views: video.Views}
-		}
+		totalLength = totalLength + video.Duration.Seconds()
+		views = append(views, float64(video.Views))
+		durations = append(durations, video.Duration.Seconds())
+	}
+	top := rankVideos(videos, "views")[0]
+	mostViewed := SimpleVideo{Title: top.Title, Views: top.Views}
+
+	// A timeseries bucket can hold nothing but zero length videos, which would
+	// otherwise divide by zero and leave a NaN that can't be encoded as JSON
+	var viewsPerMinute float64 = 0
+	if totalLength > 0 {
+		viewsPerMinute = float64(totalViews) * 60 / totalLength
 	}
 
 	return Stats{
 		TotalViews:      totalViews,
 		MeanViews:       totalViews / len(videos),
 		TotalLength:     int(totalLength),
-		ViewsPerMinute:  float64(totalViews) * 60 / totalLength,
+		ViewsPerMinute:  viewsPerMinute,
 		MostViewedVideo: mostViewed,
+		Views:           generateDistribution(views),
+		Duration:        generateDistribution(durations),
+		VideoCount:      len(videos),
 	}
 }
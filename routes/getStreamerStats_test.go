@@ -1,29 +1,59 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
 	"math"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
 	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
 )
 
 // Test route
 type MockTwitchService struct {
-	stack  []string
-	videos []twitch.Video
-	err    error
+	stack    []string
+	resolved []string
+	bypassed []bool
+	filters  []twitch.VideoFilter
+	videos   []twitch.Video
+	err      error
+	unknown  bool
+	games    []twitch.Game
+	gamesErr error
+	gamesIds [][]string
 }
 
-func (m *MockTwitchService) GetUserVideos(clientId string, limit int) ([]twitch.Video, error) {
+func (m *MockTwitchService) ResolveUserId(ctx context.Context, channel string) (string, error) {
+	m.resolved = append(m.resolved, channel)
+	if m.unknown {
+		return "", &twitch.UserNotFoundError{Channel: channel}
+	}
+	return channel, nil
+}
+
+func (m *MockTwitchService) GetUserVideos(ctx context.Context, clientId string, limit int, filter twitch.VideoFilter) ([]twitch.Video, error) {
 	m.stack = append(m.stack, fmt.Sprintf("GetUserVideos-%s-%d", clientId, limit))
+	m.filters = append(m.filters, filter)
+	m.bypassed = append(m.bypassed, twitch.CacheBypassed(ctx))
 	return m.videos, m.err
 }
 
+func (m *MockTwitchService) GetGames(ctx context.Context, ids []string) ([]twitch.Game, error) {
+	m.gamesIds = append(m.gamesIds, ids)
+	return m.games, m.gamesErr
+}
+
+func duration(s string) time.Duration {
+	d, _ := time.ParseDuration(s)
+	return d
+}
+
 func mockService(videos []twitch.Video, err error) MockTwitchService {
 	return MockTwitchService{videos: videos, err: err}
 }
@@ -40,7 +70,7 @@ func TestRouteNoParams(t *testing.T) {
 
 	service := mockService([]twitch.Video{}, nil)
 
-	RouteGetStreamerStats(c, *slog.Default(), &service)
+	RouteGetStreamerStats(c, *slog.Default(), &service, storage.NewMemoryStore())
 
 	err := errResponse(response)
 
@@ -56,7 +86,7 @@ func TestRouteNoLimit(t *testing.T) {
 
 	service := mockService([]twitch.Video{}, nil)
 
-	RouteGetStreamerStats(c, *slog.Default(), &service)
+	RouteGetStreamerStats(c, *slog.Default(), &service, storage.NewMemoryStore())
 
 	err := errResponse(response)
 
@@ -65,6 +95,23 @@ func TestRouteNoLimit(t *testing.T) {
 	}
 }
 
+func TestRouteNegativeLimit(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats?limit=-5", nil)
+
+	service := mockService([]twitch.Video{}, nil)
+
+	RouteGetStreamerStats(c, *slog.Default(), &service, storage.NewMemoryStore())
+
+	err := errResponse(response)
+
+	if !(response.Code == 400 && len(err.Errors) == 1 && len(service.resolved) == 0) {
+		t.Errorf(`Route test failed - Status %d (expected 400) | Body %v`, response.Code, err)
+	}
+}
+
 func TestRouteTwitchError(t *testing.T) {
 	response := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(response)
@@ -73,7 +120,7 @@ func TestRouteTwitchError(t *testing.T) {
 
 	service := mockService([]twitch.Video{}, &twitch.ApiError{})
 
-	RouteGetStreamerStats(c, *slog.Default(), &service)
+	RouteGetStreamerStats(c, *slog.Default(), &service, storage.NewMemoryStore())
 
 	err := errResponse(response)
 
@@ -90,11 +137,11 @@ func TestRouteNoVideos(t *testing.T) {
 
 	service := mockService([]twitch.Video{}, nil)
 
-	RouteGetStreamerStats(c, *slog.Default(), &service)
+	RouteGetStreamerStats(c, *slog.Default(), &service, storage.NewMemoryStore())
 
 	err := errResponse(response)
 
-	if !(response.Code == 404 && len(err.Errors) == 1 && len(service.stack) == 1 && service.stack[0] == "GetUserVideos-testchannel-100") {
+	if !(response.Code == 404 && len(err.Errors) == 1 && err.Errors[0] == "No videos found for this user" && len(service.stack) == 1 && service.stack[0] == "GetUserVideos-testchannel-100") {
 		t.Errorf(`Route test failed - Status %d (expected 404) | Body %v`, response.Code, err)
 	}
 }
@@ -106,24 +153,93 @@ func TestRouteSuccess(t *testing.T) {
 	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats?limit=10", nil)
 
 	service := mockService([]twitch.Video{
-		{Title: "Title 1", Views: 500, Duration: "2m1s"},
-		{Title: "Title 2", Views: 450, Duration: "3m1s"},
-		{Title: "Title 3", Views: 480, Duration: "1m1s"},
-		{Title: "Title 4", Views: 580, Duration: "3m59s"},
-		{Title: "Title 5", Views: 601, Duration: "2m22s"},
-		{Title: "Title 6", Views: 572, Duration: "1m11s"},
-		{Title: "Title 7", Views: 444, Duration: "3m33s"},
-		{Title: "Title 8", Views: 499, Duration: "3m10s"},
-		{Title: "Title 9", Views: 654, Duration: "59s"},
-		{Title: "Title 10", Views: 399, Duration: "2m"}}, nil)
-
-	RouteGetStreamerStats(c, *slog.Default(), &service)
+		{Title: "Title 1", Views: 500, Duration: duration("2m1s")},
+		{Title: "Title 2", Views: 450, Duration: duration("3m1s")},
+		{Title: "Title 3", Views: 480, Duration: duration("1m1s")},
+		{Title: "Title 4", Views: 580, Duration: duration("3m59s")},
+		{Title: "Title 5", Views: 601, Duration: duration("2m22s")},
+		{Title: "Title 6", Views: 572, Duration: duration("1m11s")},
+		{Title: "Title 7", Views: 444, Duration: duration("3m33s")},
+		{Title: "Title 8", Views: 499, Duration: duration("3m10s")},
+		{Title: "Title 9", Views: 654, Duration: duration("59s")},
+		{Title: "Title 10", Views: 399, Duration: duration("2m")}}, nil)
+
+	RouteGetStreamerStats(c, *slog.Default(), &service, storage.NewMemoryStore())
 
 	if !(response.Code == 200 && len(service.stack) == 1 && service.stack[0] == "GetUserVideos-testchannel-10") {
 		t.Errorf(`Route test failed - Status %d (expected 200) | Body %v`, response.Code, response.Body.String())
 	}
 }
 
+func TestRouteUnknownChannel(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "nobody"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/nobody/stats?limit=100", nil)
+
+	service := mockService([]twitch.Video{}, nil)
+	service.unknown = true
+
+	RouteGetStreamerStats(c, *slog.Default(), &service, storage.NewMemoryStore())
+
+	err := errResponse(response)
+
+	if !(response.Code == 404 && len(err.Errors) == 1 && err.Errors[0] == "No user found for this channel" && len(service.stack) == 0) {
+		t.Errorf(`Route test failed - Status %d (expected 404) | Body %v`, response.Code, err)
+	}
+}
+
+func TestRouteCacheControl(t *testing.T) {
+	for header, expected := range map[string]bool{"": false, "max-age=0": false, "no-cache": true, "No-Cache, max-age=0": true} {
+		response := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(response)
+		c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+		c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats?limit=10", nil)
+		c.Request.Header.Set("Cache-Control", header)
+
+		service := mockService([]twitch.Video{{Title: "Title 1", Views: 500, Duration: duration("2m1s")}}, nil)
+
+		RouteGetStreamerStats(c, *slog.Default(), &service, storage.NewMemoryStore())
+
+		if !(response.Code == 200 && len(service.bypassed) == 1 && service.bypassed[0] == expected) {
+			t.Errorf(`Route test failed - Cache-Control %q should bypass: %t | bypassed: %v`, header, expected, service.bypassed)
+		}
+	}
+}
+
+func TestRouteFilters(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats?limit=10&type=archive&period=month&sort=views&language=en", nil)
+
+	service := mockService([]twitch.Video{{Title: "Title 1", Views: 500, Duration: duration("2m1s")}}, nil)
+
+	RouteGetStreamerStats(c, *slog.Default(), &service, storage.NewMemoryStore())
+
+	expected := twitch.VideoFilter{Type: "archive", Period: "month", Sort: "views", Language: "en"}
+	if !(response.Code == 200 && len(service.filters) == 1 && service.filters[0] == expected) {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Filters %+v`, response.Code, service.filters)
+	}
+}
+
+func TestRouteInvalidFilters(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats?limit=10&type=clip&period=year&sort=likes&language=english", nil)
+
+	service := mockService([]twitch.Video{}, nil)
+
+	RouteGetStreamerStats(c, *slog.Default(), &service, storage.NewMemoryStore())
+
+	err := errResponse(response)
+
+	if !(response.Code == 400 && len(err.Errors) == 4 && len(service.stack) == 0) {
+		t.Errorf(`Route test failed - Status %d (expected 400) | Body %v`, response.Code, err)
+	}
+}
+
 //
 // Test generateStats(videos)
 //
@@ -148,17 +264,17 @@ func compareStats(t *testing.T, expected Stats, reality Stats) {
 func expected(TotalViews int, TotalLength int, MeanViews int, ViewsPerMinute float64, Title string, Views int) Stats {
 	MostViewedVideo := SimpleVideo{Title, Views}
 	return Stats{
-		TotalViews,
-		MeanViews,
-		TotalLength,
-		ViewsPerMinute,
-		MostViewedVideo,
+		TotalViews:      TotalViews,
+		MeanViews:       MeanViews,
+		TotalLength:     TotalLength,
+		ViewsPerMinute:  ViewsPerMinute,
+		MostViewedVideo: MostViewedVideo,
 	}
 }
 
 func TestGenerateStatsSingleVideo(t *testing.T) {
 	videos := []twitch.Video{
-		{Title: "Title 1", Views: 100, Duration: "1m1s"},
+		{Title: "Title 1", Views: 100, Duration: duration("1m1s")},
 	}
 	result := generateStats(videos)
 
@@ -167,16 +283,16 @@ func TestGenerateStatsSingleVideo(t *testing.T) {
 
 func TestGenerateStatsTenSameVideos(t *testing.T) {
 	videos := []twitch.Video{
-		{Title: "Title 1", Views: 100, Duration: "1m1s"},
-		{Title: "Title 2", Views: 100, Duration: "1m1s"},
-		{Title: "Title 3", Views: 100, Duration: "1m1s"},
-		{Title: "Title 4", Views: 100, Duration: "1m1s"},
-		{Title: "Title 5", Views: 100, Duration: "1m1s"},
-		{Title: "Title 6", Views: 100, Duration: "1m1s"},
-		{Title: "Title 7", Views: 100, Duration: "1m1s"},
-		{Title: "Title 8", Views: 100, Duration: "1m1s"},
-		{Title: "Title 9", Views: 100, Duration: "1m1s"},
-		{Title: "Title 10", Views: 100, Duration: "1m1s"},
+		{Title: "Title 1", Views: 100, Duration: duration("1m1s")},
+		{Title: "Title 2", Views: 100, Duration: duration("1m1s")},
+		{Title: "Title 3", Views: 100, Duration: duration("1m1s")},
+		{Title: "Title 4", Views: 100, Duration: duration("1m1s")},
+		{Title: "Title 5", Views: 100, Duration: duration("1m1s")},
+		{Title: "Title 6", Views: 100, Duration: duration("1m1s")},
+		{Title: "Title 7", Views: 100, Duration: duration("1m1s")},
+		{Title: "Title 8", Views: 100, Duration: duration("1m1s")},
+		{Title: "Title 9", Views: 100, Duration: duration("1m1s")},
+		{Title: "Title 10", Views: 100, Duration: duration("1m1s")},
 	}
 	result := generateStats(videos)
 
@@ -185,16 +301,16 @@ func TestGenerateStatsTenSameVideos(t *testing.T) {
 
 func TestGenerateStatsTenSimilarVideos(t *testing.T) {
 	videos := []twitch.Video{
-		{Title: "Title 1", Views: 500, Duration: "2m1s"},
-		{Title: "Title 2", Views: 450, Duration: "3m1s"},
-		{Title: "Title 3", Views: 480, Duration: "1m1s"},
-		{Title: "Title 4", Views: 580, Duration: "3m59s"},
-		{Title: "Title 5", Views: 601, Duration: "2m22s"},
-		{Title: "Title 6", Views: 572, Duration: "1m11s"},
-		{Title: "Title 7", Views: 444, Duration: "3m33s"},
-		{Title: "Title 8", Views: 499, Duration: "3m10s"},
-		{Title: "Title 9", Views: 654, Duration: "59s"},
-		{Title: "Title 10", Views: 399, Duration: "2m"},
+		{Title: "Title 1", Views: 500, Duration: duration("2m1s")},
+		{Title: "Title 2", Views: 450, Duration: duration("3m1s")},
+		{Title: "Title 3", Views: 480, Duration: duration("1m1s")},
+		{Title: "Title 4", Views: 580, Duration: duration("3m59s")},
+		{Title: "Title 5", Views: 601, Duration: duration("2m22s")},
+		{Title: "Title 6", Views: 572, Duration: duration("1m11s")},
+		{Title: "Title 7", Views: 444, Duration: duration("3m33s")},
+		{Title: "Title 8", Views: 499, Duration: duration("3m10s")},
+		{Title: "Title 9", Views: 654, Duration: duration("59s")},
+		{Title: "Title 10", Views: 399, Duration: duration("2m")},
 	}
 	result := generateStats(videos)
 
@@ -203,18 +319,83 @@ func TestGenerateStatsTenSimilarVideos(t *testing.T) {
 
 func TestGenerateStatsOutliers(t *testing.T) {
 	videos := []twitch.Video{
-		{Title: "Title 1", Views: 500, Duration: "2m1s"},
-		{Title: "Title 2", Views: 450, Duration: "3m1s"},
-		{Title: "Title 3", Views: 8000, Duration: "1h53m12s"},
-		{Title: "Title 4", Views: 580, Duration: "3m59s"},
-		{Title: "Title 5", Views: 601, Duration: "2m22s"},
-		{Title: "Title 6", Views: 764982, Duration: "12m"},
-		{Title: "Title 7", Views: 444, Duration: "3m33s"},
-		{Title: "Title 8", Views: 499, Duration: "3m10s"},
-		{Title: "Title 9", Views: 654, Duration: "59s"},
-		{Title: "Title 10", Views: 399, Duration: "2m"},
+		{Title: "Title 1", Views: 500, Duration: duration("2m1s")},
+		{Title: "Title 2", Views: 450, Duration: duration("3m1s")},
+		{Title: "Title 3", Views: 8000, Duration: duration("1h53m12s")},
+		{Title: "Title 4", Views: 580, Duration: duration("3m59s")},
+		{Title: "Title 5", Views: 601, Duration: duration("2m22s")},
+		{Title: "Title 6", Views: 764982, Duration: duration("12m")},
+		{Title: "Title 7", Views: 444, Duration: duration("3m33s")},
+		{Title: "Title 8", Views: 499, Duration: duration("3m10s")},
+		{Title: "Title 9", Views: 654, Duration: duration("59s")},
+		{Title: "Title 10", Views: 399, Duration: duration("2m")},
 	}
 	result := generateStats(videos)
 
 	compareStats(t, expected(777109, 8777, 77710, 5312.3550188, "Title 6", 764982), result)
 }
+
+func partialRequest(query string, videos []twitch.Video, err error) (*httptest.ResponseRecorder, MockTwitchService) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats?limit=100"+query, nil)
+
+	service := mockService(videos, err)
+	RouteGetStreamerStats(c, *slog.Default(), &service, storage.NewMemoryStore())
+	return response, service
+}
+
+func TestRoutePartial(t *testing.T) {
+	videos := []twitch.Video{
+		{Title: "Title 1", Views: 100, Duration: duration("1m")},
+		{Title: "Title 2", Views: 300, Duration: duration("1m")},
+	}
+	response, _ := partialRequest("&partial=true", videos, &twitch.UpstreamUnavailableError{Err: context.DeadlineExceeded})
+
+	var body Stats
+	json.NewDecoder(response.Body).Decode(&body)
+
+	if !(response.Code == 200 && body.Partial && body.VideoCount == 2 && body.TotalViews == 400 &&
+		body.PartialError == MessageUpstreamTimeout && body.PartialCode == CodeUpstreamTimeout) {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
+
+func TestRoutePartialNotRequested(t *testing.T) {
+	videos := []twitch.Video{{Title: "Title 1", Views: 100, Duration: duration("1m")}}
+	response, _ := partialRequest("", videos, &twitch.UpstreamUnavailableError{Err: context.DeadlineExceeded})
+
+	if response.Code != 504 {
+		t.Errorf(`Route test failed - Status %d (expected 504)`, response.Code)
+	}
+}
+
+func TestRoutePartialNothingFetched(t *testing.T) {
+	response, _ := partialRequest("&partial=true", []twitch.Video{}, &twitch.RateLimitedError{RetryAfter: time.Second})
+
+	if response.Code != 503 {
+		t.Errorf(`Route test failed - Status %d (expected 503)`, response.Code)
+	}
+}
+
+func TestRoutePartialComplete(t *testing.T) {
+	videos := []twitch.Video{{Title: "Title 1", Views: 100, Duration: duration("1m")}}
+	response, _ := partialRequest("&partial=true", videos, nil)
+
+	var body map[string]any
+	json.NewDecoder(response.Body).Decode(&body)
+
+	_, hasPartial := body["partial"]
+	if !(response.Code == 200 && !hasPartial && body["videoCount"] == float64(1)) {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
+
+func TestRoutePartialInvalid(t *testing.T) {
+	response, service := partialRequest("&partial=yes", []twitch.Video{}, nil)
+
+	if !(response.Code == 400 && len(service.stack) == 0) {
+		t.Errorf(`Route test failed - Status %d (expected 400)`, response.Code)
+	}
+}
@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+const DefaultTimeseriesBucket = "day"
+
+var TimeseriesBuckets = []string{"day", "week", "month"}
+
+type TimeseriesBucket struct {
+	Start          time.Time `json:"start"`
+	Count          int       `json:"count"`
+	TotalViews     int       `json:"totalViews"`
+	TotalHours     float64   `json:"totalHours"`
+	ViewsPerMinute float64   `json:"viewsPerMinute"`
+}
+
+type Timeseries struct {
+	Bucket  string             `json:"bucket"`
+	Buckets []TimeseriesBucket `json:"buckets"`
+}
+
+func RouteGetStreamerTimeseries(c *gin.Context, log slog.Logger, twitch ITwitch) {
+
+	input := parseInput(c)
+
+	bucket := c.DefaultQuery("bucket", DefaultTimeseriesBucket)
+	if !slices.Contains(TimeseriesBuckets, bucket) {
+		input.errors = append(input.errors, "Invalid bucket parameter")
+	}
+
+	if len(input.errors) > 0 {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, input.errors...)
+		return
+	}
+
+	result, ok := fetchVideos(c, twitch, input)
+	if !ok {
+		return
+	}
+
+	timeseries := generateTimeseries(result, bucket)
+
+	log.Debug("Returning timeseries", "bucket", bucket, "buckets", len(timeseries.Buckets))
+
+	c.JSON(http.StatusOK, timeseries)
+}
+
+// generateTimeseries groups videos by when they were created and summarises
+// each group with the same calculations as the overall stats
+func generateTimeseries(videos []twitch.Video, bucket string) Timeseries {
+	groups := map[time.Time][]twitch.Video{}
+	for _, video := range videos {
+		start := bucketStart(video.CreatedAt, bucket)
+		groups[start] = append(groups[start], video)
+	}
+
+	buckets := make([]TimeseriesBucket, 0, len(groups))
+	for start, group := range groups {
+		stats := generateStats(group)
+		// Summed from the durations themselves, as TotalLength is whole seconds
+		var length time.Duration
+		for _, video := range group {
+			length += video.Duration
+		}
+		buckets = append(buckets, TimeseriesBucket{
+			Start:          start,
+			Count:          len(group),
+			TotalViews:     stats.TotalViews,
+			TotalHours:     length.Hours(),
+			ViewsPerMinute: stats.ViewsPerMinute,
+		})
+	}
+	slices.SortFunc(buckets, func(a, b TimeseriesBucket) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	return Timeseries{Bucket: bucket, Buckets: buckets}
+}
+
+// bucketStart truncates a time to the start of its day, ISO week (starting
+// Monday) or month in UTC
+func bucketStart(t time.Time, bucket string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch bucket {
+	case "week":
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
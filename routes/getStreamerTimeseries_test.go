@@ -0,0 +1,158 @@
+package routes
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+func at(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+func timeseriesVideos() []twitch.Video {
+	return []twitch.Video{
+		{Title: "Title 1", Views: 100, Duration: duration("1h"), CreatedAt: at("2024-03-31T23:30:00Z")},
+		{Title: "Title 2", Views: 200, Duration: duration("2h"), CreatedAt: at("2024-04-01T10:00:00Z")},
+		{Title: "Title 3", Views: 300, Duration: duration("30m"), CreatedAt: at("2024-04-01T20:00:00Z")},
+		{Title: "Title 4", Views: 400, Duration: duration("1h"), CreatedAt: at("2024-04-09T12:00:00+02:00")},
+	}
+}
+
+func TestBucketStart(t *testing.T) {
+	tests := []struct {
+		time     string
+		bucket   string
+		expected string
+	}{
+		{"2024-04-03T15:04:05Z", "day", "2024-04-03T00:00:00Z"},
+		{"2024-04-03T01:00:00+02:00", "day", "2024-04-02T00:00:00Z"},
+		{"2024-04-03T15:04:05Z", "week", "2024-04-01T00:00:00Z"},
+		{"2024-04-01T00:00:00Z", "week", "2024-04-01T00:00:00Z"},
+		{"2024-04-07T23:59:59Z", "week", "2024-04-01T00:00:00Z"},
+		{"2024-01-03T10:00:00Z", "week", "2024-01-01T00:00:00Z"},
+		{"2024-04-03T15:04:05Z", "month", "2024-04-01T00:00:00Z"},
+		{"2024-12-31T23:59:59Z", "month", "2024-12-01T00:00:00Z"},
+	}
+
+	for _, test := range tests {
+		if result := bucketStart(at(test.time), test.bucket); !result.Equal(at(test.expected)) {
+			t.Errorf(`bucketStart(%s, %s) should return %s but returns %s`, test.time, test.bucket, test.expected, result)
+		}
+	}
+}
+
+func compareBuckets(t *testing.T, bucket string, expected []TimeseriesBucket, reality Timeseries) {
+	if !(reality.Bucket == bucket && len(reality.Buckets) == len(expected)) {
+		t.Errorf(`generateTimeseries(videos, %s) should return %+v but returns %+v`, bucket, expected, reality)
+		return
+	}
+	for i, e := range expected {
+		r := reality.Buckets[i]
+		if !(r.Start.Equal(e.Start) && r.Count == e.Count && r.TotalViews == e.TotalViews &&
+			floatCompare(r.TotalHours, e.TotalHours) && floatCompare(r.ViewsPerMinute, e.ViewsPerMinute)) {
+			t.Errorf(`generateTimeseries(videos, %s) bucket %d should be %+v but is %+v`, bucket, i, e, r)
+		}
+	}
+}
+
+func TestGenerateTimeseriesDay(t *testing.T) {
+	compareBuckets(t, "day", []TimeseriesBucket{
+		{Start: at("2024-03-31T00:00:00Z"), Count: 1, TotalViews: 100, TotalHours: 1, ViewsPerMinute: 1.66667},
+		{Start: at("2024-04-01T00:00:00Z"), Count: 2, TotalViews: 500, TotalHours: 2.5, ViewsPerMinute: 3.33333},
+		{Start: at("2024-04-09T00:00:00Z"), Count: 1, TotalViews: 400, TotalHours: 1, ViewsPerMinute: 6.66667},
+	}, generateTimeseries(timeseriesVideos(), "day"))
+}
+
+func TestGenerateTimeseriesWeek(t *testing.T) {
+	compareBuckets(t, "week", []TimeseriesBucket{
+		{Start: at("2024-03-25T00:00:00Z"), Count: 1, TotalViews: 100, TotalHours: 1, ViewsPerMinute: 1.66667},
+		{Start: at("2024-04-01T00:00:00Z"), Count: 2, TotalViews: 500, TotalHours: 2.5, ViewsPerMinute: 3.33333},
+		{Start: at("2024-04-08T00:00:00Z"), Count: 1, TotalViews: 400, TotalHours: 1, ViewsPerMinute: 6.66667},
+	}, generateTimeseries(timeseriesVideos(), "week"))
+}
+
+func TestGenerateTimeseriesMonth(t *testing.T) {
+	compareBuckets(t, "month", []TimeseriesBucket{
+		{Start: at("2024-03-01T00:00:00Z"), Count: 1, TotalViews: 100, TotalHours: 1, ViewsPerMinute: 1.66667},
+		{Start: at("2024-04-01T00:00:00Z"), Count: 3, TotalViews: 900, TotalHours: 3.5, ViewsPerMinute: 4.28571},
+	}, generateTimeseries(timeseriesVideos(), "month"))
+}
+
+func TestGenerateTimeseriesSubSecondHours(t *testing.T) {
+	videos := []twitch.Video{
+		{Views: 10, Duration: 1800 * time.Millisecond, CreatedAt: at("2024-04-01T10:00:00Z")},
+		{Views: 10, Duration: 1800 * time.Millisecond, CreatedAt: at("2024-04-01T11:00:00Z")},
+	}
+	result := generateTimeseries(videos, "day")
+
+	if !(len(result.Buckets) == 1 && result.Buckets[0].TotalHours == (3600*time.Millisecond).Hours()) {
+		t.Errorf(`TestGenerateTimeseriesSubSecondHours failed - buckets: %+v`, result.Buckets)
+	}
+}
+
+func TestGenerateTimeseriesZeroLength(t *testing.T) {
+	videos := []twitch.Video{{Views: 10, CreatedAt: at("2024-04-01T10:00:00Z")}}
+	result := generateTimeseries(videos, "day")
+
+	_, err := json.Marshal(result)
+	if !(err == nil && result.Buckets[0].ViewsPerMinute == 0 && result.Buckets[0].TotalHours == 0) {
+		t.Errorf(`TestGenerateTimeseriesZeroLength failed - buckets: %+v | err: %v`, result.Buckets, err)
+	}
+}
+
+func TestRouteTimeseriesInvalidBucket(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats/timeseries?limit=10&bucket=year", nil)
+
+	service := mockService(timeseriesVideos(), nil)
+
+	RouteGetStreamerTimeseries(c, *slog.Default(), &service)
+
+	err := errResponse(response)
+
+	if !(response.Code == 400 && len(err.Errors) == 1 && len(service.stack) == 0) {
+		t.Errorf(`Route test failed - Status %d (expected 400) | Body %v`, response.Code, err)
+	}
+}
+
+func TestRouteTimeseriesSuccess(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats/timeseries?limit=10&bucket=month", nil)
+
+	service := mockService(timeseriesVideos(), nil)
+
+	RouteGetStreamerTimeseries(c, *slog.Default(), &service)
+
+	var body Timeseries
+	json.NewDecoder(response.Body).Decode(&body)
+
+	if !(response.Code == 200 && body.Bucket == "month" && len(body.Buckets) == 2 && service.stack[0] == "GetUserVideos-testchannel-10") {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
+
+func TestRouteTimeseriesNoVideos(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats/timeseries?limit=10", nil)
+
+	service := mockService([]twitch.Video{}, nil)
+
+	RouteGetStreamerTimeseries(c, *slog.Default(), &service)
+
+	if response.Code != 404 {
+		t.Errorf(`Route test failed - Status %d (expected 404)`, response.Code)
+	}
+}
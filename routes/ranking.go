@@ -0,0 +1,117 @@
+package routes
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+const MaxRankedVideos = 100
+
+var RankByOptions = []string{"views", "viewsPerMinute"}
+
+type RankedVideo struct {
+	ID             string    `json:"id"`
+	Title          string    `json:"title"`
+	Views          int       `json:"views"`
+	Duration       int       `json:"duration"`
+	ViewsPerMinute float64   `json:"viewsPerMinute"`
+	URL            string    `json:"url"`
+	CreatedAt      time.Time `json:"createdAt"`
+	// The game is only filled in when a category breakdown was asked for
+	GameID   string `json:"gameId,omitempty"`
+	GameName string `json:"gameName,omitempty"`
+}
+
+type rankingInput struct {
+	top    int
+	bottom int
+	by     string
+	errors []string
+}
+
+func parseRanking(c *gin.Context) rankingInput {
+	input := rankingInput{by: c.DefaultQuery("rankBy", "views"), errors: []string{}}
+
+	input.top = parseRankCount(c, "top", &input.errors)
+	input.bottom = parseRankCount(c, "bottom", &input.errors)
+	if !slices.Contains(RankByOptions, input.by) {
+		input.errors = append(input.errors, "Invalid rankBy parameter")
+	}
+	return input
+}
+
+func parseRankCount(c *gin.Context, name string, errors *[]string) int {
+	value, exists := c.GetQuery(name)
+	if !exists {
+		return 0
+	}
+	count, err := strconv.Atoi(value)
+	if err != nil || count < 0 || count > MaxRankedVideos {
+		*errors = append(*errors, fmt.Sprintf("Invalid %s parameter, must be between 0 and %d", name, MaxRankedVideos))
+		return 0
+	}
+	return count
+}
+
+func videoViewsPerMinute(video twitch.Video) float64 {
+	if video.Duration <= 0 {
+		return 0
+	}
+	return float64(video.Views) / video.Duration.Minutes()
+}
+
+// rankVideos orders videos best first by the given metric. Ties go to the
+// video created first and then to the lowest ID, so the order never depends
+// on the order twitch happened to return the videos in.
+func rankVideos(videos []twitch.Video, by string) []twitch.Video {
+	metric := func(video twitch.Video) float64 { return float64(video.Views) }
+	if by == "viewsPerMinute" {
+		metric = videoViewsPerMinute
+	}
+
+	ranked := slices.Clone(videos)
+	slices.SortStableFunc(ranked, func(a, b twitch.Video) int {
+		return cmp.Or(
+			cmp.Compare(metric(b), metric(a)),
+			a.CreatedAt.Compare(b.CreatedAt),
+			strings.Compare(a.ID, b.ID),
+		)
+	})
+	return ranked
+}
+
+// topVideos returns the best n videos and bottomVideos the worst n, worst
+// first, so a tie at the bottom goes to the video that rankVideos puts last
+func topVideos(videos []twitch.Video, n int, by string) []RankedVideo {
+	ranked := rankVideos(videos, by)
+	return toRankedVideos(ranked[:min(n, len(ranked))])
+}
+
+func bottomVideos(videos []twitch.Video, n int, by string) []RankedVideo {
+	ranked := rankVideos(videos, by)
+	slices.Reverse(ranked)
+	return toRankedVideos(ranked[:min(n, len(ranked))])
+}
+
+func toRankedVideos(videos []twitch.Video) []RankedVideo {
+	ranked := make([]RankedVideo, 0, len(videos))
+	for _, video := range videos {
+		ranked = append(ranked, RankedVideo{
+			ID:             video.ID,
+			Title:          video.Title,
+			Views:          video.Views,
+			Duration:       int(video.Duration.Seconds()),
+			ViewsPerMinute: videoViewsPerMinute(video),
+			URL:            video.URL,
+			CreatedAt:      video.CreatedAt,
+		})
+	}
+	return ranked
+}
@@ -0,0 +1,145 @@
+package routes
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+func rankingVideos() []twitch.Video {
+	return []twitch.Video{
+		{ID: "3", Title: "Title 3", Views: 500, Duration: duration("1h"), CreatedAt: at("2024-04-03T00:00:00Z")},
+		{ID: "1", Title: "Title 1", Views: 500, Duration: duration("2h"), CreatedAt: at("2024-04-01T00:00:00Z")},
+		{ID: "2", Title: "Title 2", Views: 100, Duration: duration("10m"), CreatedAt: at("2024-04-02T00:00:00Z")},
+		{ID: "5", Title: "Title 5", Views: 900, Duration: duration("10h"), CreatedAt: at("2024-04-05T00:00:00Z")},
+		{ID: "4", Title: "Title 4", Views: 500, Duration: duration("1h"), CreatedAt: at("2024-04-03T00:00:00Z")},
+	}
+}
+
+func rankedIds(videos []RankedVideo) []string {
+	ids := []string{}
+	for _, video := range videos {
+		ids = append(ids, video.ID)
+	}
+	return ids
+}
+
+func TestRankVideos(t *testing.T) {
+	tests := []struct {
+		name     string
+		by       string
+		n        int
+		bottom   bool
+		expected []string
+	}{
+		{"top by views breaks ties by created then id", "views", 5, false, []string{"5", "1", "3", "4", "2"}},
+		{"top by views limited", "views", 2, false, []string{"5", "1"}},
+		{"bottom by views", "views", 3, true, []string{"2", "4", "3"}},
+		{"top by views per minute", "viewsPerMinute", 3, false, []string{"2", "3", "4"}},
+		{"bottom by views per minute", "viewsPerMinute", 2, true, []string{"5", "1"}},
+		{"more than available", "views", 10, false, []string{"5", "1", "3", "4", "2"}},
+	}
+
+	for _, test := range tests {
+		var result []RankedVideo
+		if test.bottom {
+			result = bottomVideos(rankingVideos(), test.n, test.by)
+		} else {
+			result = topVideos(rankingVideos(), test.n, test.by)
+		}
+		if ids := rankedIds(result); !slices.Equal(ids, test.expected) {
+			t.Errorf(`%s: should return %v but returns %v`, test.name, test.expected, ids)
+		}
+	}
+}
+
+func TestRankVideosIgnoresInputOrder(t *testing.T) {
+	videos := rankingVideos()
+	reversed := slices.Clone(videos)
+	slices.Reverse(reversed)
+
+	if a, b := rankedIds(topVideos(videos, 5, "views")), rankedIds(topVideos(reversed, 5, "views")); !slices.Equal(a, b) {
+		t.Errorf(`rankVideos depends on input order - %v vs %v`, a, b)
+	}
+}
+
+func TestToRankedVideos(t *testing.T) {
+	video := twitch.Video{ID: "1", Title: "Title 1", Views: 120, Duration: duration("1h1m"), URL: "https://www.twitch.tv/videos/1", CreatedAt: at("2024-04-01T00:00:00Z")}
+	result := toRankedVideos([]twitch.Video{video})[0]
+
+	if !(result.ID == "1" && result.Title == "Title 1" && result.Views == 120 && result.Duration == 3660 &&
+		floatCompare(result.ViewsPerMinute, 1.96721) && result.URL == video.URL && result.CreatedAt.Equal(video.CreatedAt)) {
+		t.Errorf(`toRankedVideos returned %+v`, result)
+	}
+}
+
+func TestGenerateStatsZeroViews(t *testing.T) {
+	videos := []twitch.Video{
+		{Title: "Title 1", Views: 0, Duration: duration("1m")},
+		{Title: "Title 2", Views: 0, Duration: duration("1m")},
+	}
+	result := generateStats(videos)
+
+	if result.MostViewedVideo.Title != "Title 1" {
+		t.Errorf(`generateStats(videos) most viewed should be Title 1 but is %+v`, result.MostViewedVideo)
+	}
+}
+
+func TestRouteRanking(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats?limit=10&top=2&bottom=1&rankBy=viewsPerMinute", nil)
+
+	service := mockService(rankingVideos(), nil)
+
+	RouteGetStreamerStats(c, *slog.Default(), &service, storage.NewMemoryStore())
+
+	var body Stats
+	json.NewDecoder(response.Body).Decode(&body)
+
+	if !(response.Code == 200 && slices.Equal(rankedIds(body.TopVideos), []string{"2", "3"}) && slices.Equal(rankedIds(body.BottomVideos), []string{"5"})) {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
+
+func TestRouteRankingOmittedByDefault(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats?limit=10", nil)
+
+	service := mockService(rankingVideos(), nil)
+
+	RouteGetStreamerStats(c, *slog.Default(), &service, storage.NewMemoryStore())
+
+	var body map[string]any
+	json.NewDecoder(response.Body).Decode(&body)
+
+	if _, exists := body["topVideos"]; response.Code != 200 || exists {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %v`, response.Code, body)
+	}
+}
+
+func TestRouteRankingInvalid(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: "testchannel"})
+	c.Request = httptest.NewRequest("GET", "localhost:3000/streamer/testchannel/stats?limit=10&top=-1&bottom=1000&rankBy=likes", nil)
+
+	service := mockService(rankingVideos(), nil)
+
+	RouteGetStreamerStats(c, *slog.Default(), &service, storage.NewMemoryStore())
+
+	err := errResponse(response)
+
+	if !(response.Code == 400 && len(err.Errors) == 3 && len(service.stack) == 0) {
+		t.Errorf(`Route test failed - Status %d (expected 400) | Body %v`, response.Code, err)
+	}
+}
@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/poller"
+)
+
+const MessageNotWatched = "Channel is not on the watchlist"
+
+type IWatchlist interface {
+	Add(string) (poller.WatchedChannel, bool, error)
+	Remove(string) bool
+	Get(string) (poller.WatchedChannel, bool)
+	Channels() []poller.WatchedChannel
+}
+
+type Watchlist struct {
+	Channels []poller.WatchedChannel `json:"channels"`
+}
+
+func RouteGetWatchlist(c *gin.Context, log slog.Logger, watchlist IWatchlist) {
+	c.JSON(http.StatusOK, Watchlist{Channels: watchlist.Channels()})
+}
+
+func RouteGetWatchedChannel(c *gin.Context, log slog.Logger, watchlist IWatchlist) {
+	watched, ok := watchlist.Get(c.Param("channelId"))
+	if !ok {
+		respondError(c, http.StatusNotFound, CodeNotWatched, MessageNotWatched)
+		return
+	}
+	c.JSON(http.StatusOK, watched)
+}
+
+// RoutePutWatchedChannel is idempotent, responding 201 only when the channel
+// wasn't already on the watchlist
+func RoutePutWatchedChannel(c *gin.Context, log slog.Logger, watchlist IWatchlist) {
+	watched, added, err := watchlist.Add(c.Param("channelId"))
+
+	var invalid *poller.InvalidChannelError
+	if errors.As(err, &invalid) {
+		respondError(c, http.StatusBadRequest, CodeInvalidRequest, "Invalid channel ID")
+		return
+	}
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, CodeInternal, MessageUnknown)
+		return
+	}
+
+	if !added {
+		c.JSON(http.StatusOK, watched)
+		return
+	}
+	log.Info("Added channel to watchlist", "channel", watched.Channel)
+	c.JSON(http.StatusCreated, watched)
+}
+
+func RouteDeleteWatchedChannel(c *gin.Context, log slog.Logger, watchlist IWatchlist) {
+	channel := c.Param("channelId")
+	if !watchlist.Remove(channel) {
+		respondError(c, http.StatusNotFound, CodeNotWatched, MessageNotWatched)
+		return
+	}
+	log.Info("Removed channel from watchlist", "channel", channel)
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/trelltron/twitch-stats-agg-demo/services/poller"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+func watchlistRequest(method string, channel string) (*httptest.ResponseRecorder, *gin.Context) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Params = append(c.Params, gin.Param{Key: "channelId", Value: channel})
+	c.Request = httptest.NewRequest(method, "localhost:3000/watchlist/"+url.PathEscape(channel), nil)
+	return response, c
+}
+
+func mockWatchlist(channels ...string) *poller.Poller {
+	service := mockService([]twitch.Video{}, nil)
+	return poller.NewPoller(*slog.Default(), &service, channels...)
+}
+
+func TestRouteWatchlist(t *testing.T) {
+	response := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(response)
+	c.Request = httptest.NewRequest("GET", "localhost:3000/watchlist", nil)
+
+	RouteGetWatchlist(c, *slog.Default(), mockWatchlist("beta", "alpha"))
+
+	var body Watchlist
+	json.NewDecoder(response.Body).Decode(&body)
+
+	if !(response.Code == 200 && len(body.Channels) == 2 && body.Channels[0].Channel == "alpha") {
+		t.Errorf(`Route test failed - Status %d (expected 200) | Body %+v`, response.Code, body)
+	}
+}
+
+func TestRoutePutWatchedChannel(t *testing.T) {
+	watchlist := mockWatchlist("alpha")
+
+	created, c := watchlistRequest("PUT", "Beta")
+	RoutePutWatchedChannel(c, *slog.Default(), watchlist)
+	existing, c := watchlistRequest("PUT", "alpha")
+	RoutePutWatchedChannel(c, *slog.Default(), watchlist)
+	invalid, c := watchlistRequest("PUT", "not valid")
+	RoutePutWatchedChannel(c, *slog.Default(), watchlist)
+
+	var body poller.WatchedChannel
+	json.NewDecoder(created.Body).Decode(&body)
+
+	if !(created.Code == 201 && body.Channel == "beta" && existing.Code == 200 && invalid.Code == 400 &&
+		len(watchlist.Channels()) == 2) {
+		t.Errorf(`Route test failed - Status %d, %d, %d (expected 201, 200, 400) | Body %+v`, created.Code, existing.Code, invalid.Code, body)
+	}
+}
+
+func TestRouteGetWatchedChannel(t *testing.T) {
+	watchlist := mockWatchlist("alpha")
+
+	found, c := watchlistRequest("GET", "alpha")
+	RouteGetWatchedChannel(c, *slog.Default(), watchlist)
+	missing, c := watchlistRequest("GET", "beta")
+	RouteGetWatchedChannel(c, *slog.Default(), watchlist)
+
+	if !(found.Code == 200 && missing.Code == 404) {
+		t.Errorf(`Route test failed - Status %d, %d (expected 200, 404)`, found.Code, missing.Code)
+	}
+}
+
+func TestRouteDeleteWatchedChannel(t *testing.T) {
+	watchlist := mockWatchlist("alpha")
+
+	_, removed := watchlistRequest("DELETE", "alpha")
+	RouteDeleteWatchedChannel(removed, *slog.Default(), watchlist)
+	missing, c := watchlistRequest("DELETE", "alpha")
+	RouteDeleteWatchedChannel(c, *slog.Default(), watchlist)
+
+	// gin only writes a bodyless status once the handler chain finishes
+	if !(removed.Writer.Status() == 204 && missing.Code == 404 && len(watchlist.Channels()) == 0) {
+		t.Errorf(`Route test failed - Status %d, %d (expected 204, 404)`, removed.Writer.Status(), missing.Code)
+	}
+}
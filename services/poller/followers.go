@@ -0,0 +1,105 @@
+package poller
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+)
+
+const (
+	DefaultFollowerSampleInterval = time.Hour
+	DefaultFollowerSampleJitter   = 0.1
+)
+
+type IFollowers interface {
+	ResolveUserId(context.Context, string) (string, error)
+	GetFollowerCount(context.Context, string) (int, error)
+}
+
+type IFollowerRecorder interface {
+	RecordFollowerSamples([]storage.FollowerSample) error
+}
+
+// FollowerSampler records the follower total of every channel on the
+// watchlist each interval. Totals take a request per channel, but change
+// slowly enough that an hourly sample is plenty to measure growth over days.
+type FollowerSampler struct {
+	Log       slog.Logger
+	Twitch    IFollowers
+	Store     IFollowerRecorder
+	Watchlist *Poller
+	Interval  time.Duration
+	Jitter    float64
+}
+
+func BuildFollowerSampler(log slog.Logger, service IFollowers, store IFollowerRecorder, watchlist *Poller) *FollowerSampler {
+	sampler := &FollowerSampler{
+		Log:       log,
+		Twitch:    service,
+		Store:     store,
+		Watchlist: watchlist,
+		Interval:  DefaultFollowerSampleInterval,
+		Jitter:    DefaultFollowerSampleJitter,
+	}
+
+	if value, exists := os.LookupEnv("FOLLOWER_SAMPLE_INTERVAL"); exists {
+		if interval, err := time.ParseDuration(value); err == nil && interval >= 0 {
+			sampler.Interval = interval
+		} else {
+			log.Warn("Ignoring invalid FOLLOWER_SAMPLE_INTERVAL", "value", value)
+		}
+	}
+	if value, exists := os.LookupEnv("FOLLOWER_SAMPLE_JITTER"); exists {
+		if jitter, err := strconv.ParseFloat(value, 64); err == nil && jitter >= 0 && jitter <= 1 {
+			sampler.Jitter = jitter
+		} else {
+			log.Warn("Ignoring invalid FOLLOWER_SAMPLE_JITTER", "value", value)
+		}
+	}
+
+	log.Debug("Initialising follower sampler", "interval", sampler.Interval, "jitter", sampler.Jitter)
+	return sampler
+}
+
+// Run samples every interval until the context is cancelled. An interval of
+// 0 disables sampling.
+func (s *FollowerSampler) Run(ctx context.Context) {
+	if s.Interval <= 0 {
+		s.Log.Info("Follower sampling disabled")
+		return
+	}
+	runEvery(ctx, s.Interval, s.Jitter, s.Sample)
+}
+
+func (s *FollowerSampler) Sample(ctx context.Context) {
+	samples := []storage.FollowerSample{}
+	for _, watched := range s.Watchlist.Channels() {
+		if ctx.Err() != nil {
+			return
+		}
+		userId, err := s.Twitch.ResolveUserId(ctx, watched.Channel)
+		if err != nil {
+			s.Log.Warn("Failed to resolve watched channel for follower sampling", "channel", watched.Channel, "err", err)
+			continue
+		}
+		followers, err := s.Twitch.GetFollowerCount(ctx, userId)
+		if err != nil {
+			s.Log.Warn("Failed to fetch follower count", "channel", watched.Channel, "err", err)
+			continue
+		}
+		samples = append(samples, storage.FollowerSample{UserID: userId, Followers: followers, RecordedAt: time.Now().UTC()})
+	}
+	if len(samples) == 0 {
+		return
+	}
+
+	if err := s.Store.RecordFollowerSamples(samples); err != nil {
+		s.Log.Error("Failed to record follower samples", "count", len(samples), "err", err)
+		return
+	}
+	s.Log.Debug("Sampled followers", "channels", len(samples))
+}
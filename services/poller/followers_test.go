@@ -0,0 +1,39 @@
+package poller
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+type MockFollowers struct {
+	MockTwitch
+	requested []string
+}
+
+func (m *MockFollowers) GetFollowerCount(ctx context.Context, userId string) (int, error) {
+	m.requested = append(m.requested, userId)
+	if userId == "id-beta" {
+		return 0, &twitch.UpstreamUnavailableError{}
+	}
+	return 500, nil
+}
+
+func TestFollowerSample(t *testing.T) {
+	service := &MockFollowers{MockTwitch: MockTwitch{failing: "gamma"}}
+	store := storage.NewMemoryStore()
+	watchlist := NewPoller(*slog.Default(), service, "alpha", "beta", "gamma")
+	sampler := &FollowerSampler{Log: *slog.Default(), Twitch: service, Store: store, Watchlist: watchlist}
+
+	sampler.Sample(context.Background())
+	alpha, _ := store.FollowerSamples("id-alpha", time.Time{}, time.Time{})
+	beta, _ := store.FollowerSamples("id-beta", time.Time{}, time.Time{})
+
+	if !(len(service.requested) == 2 && len(alpha) == 1 && alpha[0].Followers == 500 && !alpha[0].RecordedAt.IsZero() && len(beta) == 0) {
+		t.Errorf(`TestFollowerSample failed - requested: %v | alpha: %+v | beta: %+v`, service.requested, alpha, beta)
+	}
+}
@@ -0,0 +1,243 @@
+package poller
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+const (
+	DefaultPollInterval = 15 * time.Minute
+	DefaultPollJitter   = 0.1
+	DefaultPollLimit    = 100
+)
+
+// Twitch logins are 4-25 characters, but older accounts can be shorter
+var channelPattern = regexp.MustCompile(`^[a-z0-9_]{1,25}$`)
+
+type ITwitch interface {
+	ResolveUserId(context.Context, string) (string, error)
+	GetUserVideos(context.Context, string, int, twitch.VideoFilter) ([]twitch.Video, error)
+}
+
+type InvalidChannelError struct {
+	Channel string
+}
+
+func (e *InvalidChannelError) Error() string {
+	return "Invalid channel " + strconv.Quote(e.Channel)
+}
+
+// WatchedChannel is a channel on the watchlist and how its last poll went
+type WatchedChannel struct {
+	Channel    string    `json:"channel"`
+	AddedAt    time.Time `json:"addedAt"`
+	LastPolled time.Time `json:"lastPolled,omitzero"`
+	Videos     int       `json:"videos"`
+	LastError  string    `json:"lastError,omitempty"`
+}
+
+// Poller fetches the videos of every channel on its watchlist each interval,
+// bypassing the cache so a fresh snapshot of each video is recorded. Channels
+// are polled one at a time, each request waiting on the client's rate limiter
+// like any other, so polling never bursts past the Helix limits.
+type Poller struct {
+	Log      slog.Logger
+	Twitch   ITwitch
+	Interval time.Duration
+	Jitter   float64
+	Limit    int
+
+	lock     sync.Mutex
+	channels map[string]*WatchedChannel
+}
+
+func NewPoller(log slog.Logger, service ITwitch, channels ...string) *Poller {
+	poller := &Poller{
+		Log:      log,
+		Twitch:   service,
+		Interval: DefaultPollInterval,
+		Jitter:   DefaultPollJitter,
+		Limit:    DefaultPollLimit,
+		channels: map[string]*WatchedChannel{},
+	}
+	for _, channel := range channels {
+		if _, _, err := poller.Add(channel); err != nil {
+			log.Warn("Ignoring invalid watchlist channel", "channel", channel)
+		}
+	}
+	return poller
+}
+
+func BuildPoller(log slog.Logger, service ITwitch) *Poller {
+	channels := []string{}
+	if value, exists := os.LookupEnv("WATCHLIST"); exists {
+		for _, channel := range strings.Split(value, ",") {
+			if channel = strings.TrimSpace(channel); channel != "" {
+				channels = append(channels, channel)
+			}
+		}
+	}
+	poller := NewPoller(log, service, channels...)
+
+	if value, exists := os.LookupEnv("POLL_INTERVAL"); exists {
+		if interval, err := time.ParseDuration(value); err == nil && interval >= 0 {
+			poller.Interval = interval
+		} else {
+			log.Warn("Ignoring invalid POLL_INTERVAL", "value", value)
+		}
+	}
+	if value, exists := os.LookupEnv("POLL_JITTER"); exists {
+		if jitter, err := strconv.ParseFloat(value, 64); err == nil && jitter >= 0 && jitter <= 1 {
+			poller.Jitter = jitter
+		} else {
+			log.Warn("Ignoring invalid POLL_JITTER", "value", value)
+		}
+	}
+	if value, exists := os.LookupEnv("POLL_LIMIT"); exists {
+		if limit, err := strconv.Atoi(value); err == nil && limit > 0 {
+			poller.Limit = limit
+		} else {
+			log.Warn("Ignoring invalid POLL_LIMIT", "value", value)
+		}
+	}
+
+	log.Debug("Initialising watchlist poller", "interval", poller.Interval, "jitter", poller.Jitter, "limit", poller.Limit, "channels", poller.Channels())
+	return poller
+}
+
+// Add puts a channel on the watchlist, returning false if it already was
+func (p *Poller) Add(channel string) (WatchedChannel, bool, error) {
+	channel = strings.ToLower(channel)
+	if !channelPattern.MatchString(channel) {
+		return WatchedChannel{}, false, &InvalidChannelError{Channel: channel}
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if existing, ok := p.channels[channel]; ok {
+		return *existing, false, nil
+	}
+	watched := &WatchedChannel{Channel: channel, AddedAt: time.Now().UTC()}
+	p.channels[channel] = watched
+	return *watched, true, nil
+}
+
+// Remove takes a channel off the watchlist, returning false if it wasn't on it
+func (p *Poller) Remove(channel string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	channel = strings.ToLower(channel)
+	_, ok := p.channels[channel]
+	delete(p.channels, channel)
+	return ok
+}
+
+func (p *Poller) Get(channel string) (WatchedChannel, bool) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	watched, ok := p.channels[strings.ToLower(channel)]
+	if !ok {
+		return WatchedChannel{}, false
+	}
+	return *watched, true
+}
+
+// Channels returns the watchlist in alphabetical order
+func (p *Poller) Channels() []WatchedChannel {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	channels := make([]WatchedChannel, 0, len(p.channels))
+	for _, watched := range p.channels {
+		channels = append(channels, *watched)
+	}
+	slices.SortFunc(channels, func(a, b WatchedChannel) int {
+		return strings.Compare(a.Channel, b.Channel)
+	})
+	return channels
+}
+
+// Run polls the watchlist every interval until the context is cancelled. An
+// interval of 0 disables polling, though the watchlist can still be managed.
+func (p *Poller) Run(ctx context.Context) {
+	if p.Interval <= 0 {
+		p.Log.Info("Watchlist polling disabled")
+		return
+	}
+	runEvery(ctx, p.Interval, p.Jitter, p.PollAll)
+}
+
+// PollAll polls each channel on the watchlist in turn
+func (p *Poller) PollAll(ctx context.Context) {
+	for _, watched := range p.Channels() {
+		if ctx.Err() != nil {
+			return
+		}
+		p.poll(ctx, watched.Channel)
+	}
+}
+
+func (p *Poller) poll(ctx context.Context, channel string) {
+	ctx = twitch.WithoutCache(ctx)
+
+	var videos []twitch.Video
+	userId, err := p.Twitch.ResolveUserId(ctx, channel)
+	if err == nil {
+		videos, err = p.Twitch.GetUserVideos(ctx, userId, p.Limit, twitch.VideoFilter{})
+	}
+	if err != nil {
+		p.Log.Warn("Failed to poll watched channel", "channel", channel, "err", err)
+	} else {
+		p.Log.Debug("Polled watched channel", "channel", channel, "videos", len(videos))
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	// The channel may have been removed while it was being polled
+	if watched, ok := p.channels[channel]; ok {
+		watched.LastPolled = time.Now().UTC()
+		watched.Videos = len(videos)
+		watched.LastError = ""
+		if err != nil {
+			watched.LastError = err.Error()
+		}
+	}
+}
+
+// runEvery calls run straight away and then again every interval, spread by
+// up to +/- jitter of itself so restarts don't leave several instances
+// hitting twitch in lockstep, until the context is cancelled
+func runEvery(ctx context.Context, interval time.Duration, jitter float64, run func(context.Context)) {
+	for {
+		run(ctx)
+
+		timer := time.NewTimer(jitterDelay(interval, jitter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func jitterDelay(interval time.Duration, jitter float64) time.Duration {
+	if jitter > 0 {
+		return time.Duration(float64(interval) * (1 + jitter*(2*rand.Float64()-1)))
+	}
+	return interval
+}
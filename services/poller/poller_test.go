@@ -0,0 +1,122 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+type MockTwitch struct {
+	lock     sync.Mutex
+	stack    []string
+	bypassed []bool
+	failing  string
+}
+
+func (m *MockTwitch) ResolveUserId(ctx context.Context, channel string) (string, error) {
+	if channel == m.failing {
+		return "", &twitch.UserNotFoundError{Channel: channel}
+	}
+	return "id-" + channel, nil
+}
+
+func (m *MockTwitch) GetUserVideos(ctx context.Context, userId string, limit int, filter twitch.VideoFilter) ([]twitch.Video, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.stack = append(m.stack, fmt.Sprintf("GetUserVideos-%s-%d", userId, limit))
+	m.bypassed = append(m.bypassed, twitch.CacheBypassed(ctx))
+	return make([]twitch.Video, 3), nil
+}
+
+func (m *MockTwitch) calls() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.stack)
+}
+
+func TestWatchlist(t *testing.T) {
+	poller := NewPoller(*slog.Default(), &MockTwitch{}, "Beta", "alpha", "not a channel")
+
+	_, added, err := poller.Add("gamma")
+	_, again, _ := poller.Add("GAMMA")
+	_, _, invalid := poller.Add("bad/channel")
+	removed := poller.Remove("beta")
+	missing := poller.Remove("beta")
+	_, found := poller.Get("Alpha")
+	channels := poller.Channels()
+
+	var invalidErr *InvalidChannelError
+	if !(err == nil && added && !again && errors.As(invalid, &invalidErr) && removed && !missing && found &&
+		len(channels) == 2 && channels[0].Channel == "alpha" && channels[1].Channel == "gamma") {
+		t.Errorf(`TestWatchlist failed - channels: %+v | added: %t, %t | removed: %t, %t | err: %v, %v`, channels, added, again, removed, missing, err, invalid)
+	}
+}
+
+func TestPollAll(t *testing.T) {
+	service := &MockTwitch{failing: "beta"}
+	poller := NewPoller(*slog.Default(), service, "alpha", "beta")
+	poller.Limit = 20
+
+	poller.PollAll(context.Background())
+	alpha, _ := poller.Get("alpha")
+	beta, _ := poller.Get("beta")
+
+	if !(len(service.stack) == 1 && service.stack[0] == "GetUserVideos-id-alpha-20" && service.bypassed[0] &&
+		alpha.Videos == 3 && alpha.LastError == "" && !alpha.LastPolled.IsZero() &&
+		beta.Videos == 0 && beta.LastError != "" && !beta.LastPolled.IsZero()) {
+		t.Errorf(`TestPollAll failed - stack: %v | alpha: %+v | beta: %+v`, service.stack, alpha, beta)
+	}
+}
+
+func TestRunPollsEachInterval(t *testing.T) {
+	service := &MockTwitch{}
+	poller := NewPoller(*slog.Default(), service, "alpha")
+	poller.Interval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 55*time.Millisecond)
+	defer cancel()
+	poller.Run(ctx)
+
+	if calls := service.calls(); !(calls >= 3 && calls <= 7) {
+		t.Errorf(`TestRunPollsEachInterval failed - polled %d times`, calls)
+	}
+}
+
+func TestRunDisabled(t *testing.T) {
+	service := &MockTwitch{}
+	poller := NewPoller(*slog.Default(), service, "alpha")
+	poller.Interval = 0
+
+	poller.Run(context.Background())
+
+	if service.calls() != 0 {
+		t.Errorf(`TestRunDisabled failed - polled %d times`, service.calls())
+	}
+}
+
+func TestDelayJitter(t *testing.T) {
+	for range 100 {
+		if delay := jitterDelay(time.Minute, 0.5); delay < 30*time.Second || delay > 90*time.Second {
+			t.Errorf(`TestDelayJitter failed - delay %s out of range`, delay)
+		}
+	}
+}
+
+func TestBuildPollerFromEnv(t *testing.T) {
+	t.Setenv("WATCHLIST", "alpha, beta,,")
+	t.Setenv("POLL_INTERVAL", "1m")
+	t.Setenv("POLL_JITTER", "2")
+	t.Setenv("POLL_LIMIT", "50")
+
+	poller := BuildPoller(*slog.Default(), &MockTwitch{})
+
+	if !(len(poller.Channels()) == 2 && poller.Interval == time.Minute && poller.Jitter == DefaultPollJitter && poller.Limit == 50) {
+		t.Errorf(`TestBuildPollerFromEnv failed - poller: %+v`, poller)
+	}
+}
@@ -0,0 +1,107 @@
+package poller
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+const (
+	// Cheap enough to be on by default, as it takes one request for the
+	// whole watchlist, and often enough to catch short broadcasts and the
+	// game of each one
+	DefaultSampleInterval = 5 * time.Minute
+	DefaultSampleJitter   = 0.1
+)
+
+type IStreams interface {
+	ResolveUserId(context.Context, string) (string, error)
+	GetStreams(context.Context, []string) ([]twitch.Stream, error)
+}
+
+type IViewerRecorder interface {
+	RecordViewerSamples([]storage.ViewerSample) error
+}
+
+// Sampler records the concurrent viewer count of every live channel on the
+// watchlist each interval. All of the channels are checked with one streams
+// request, so sampling often costs little of the rate limit.
+type Sampler struct {
+	Log       slog.Logger
+	Twitch    IStreams
+	Store     IViewerRecorder
+	Watchlist *Poller
+	Interval  time.Duration
+	Jitter    float64
+}
+
+func BuildSampler(log slog.Logger, service IStreams, store IViewerRecorder, watchlist *Poller) *Sampler {
+	sampler := &Sampler{
+		Log:       log,
+		Twitch:    service,
+		Store:     store,
+		Watchlist: watchlist,
+		Interval:  DefaultSampleInterval,
+		Jitter:    DefaultSampleJitter,
+	}
+
+	if value, exists := os.LookupEnv("VIEWER_SAMPLE_INTERVAL"); exists {
+		if interval, err := time.ParseDuration(value); err == nil && interval >= 0 {
+			sampler.Interval = interval
+		} else {
+			log.Warn("Ignoring invalid VIEWER_SAMPLE_INTERVAL", "value", value)
+		}
+	}
+	if value, exists := os.LookupEnv("VIEWER_SAMPLE_JITTER"); exists {
+		if jitter, err := strconv.ParseFloat(value, 64); err == nil && jitter >= 0 && jitter <= 1 {
+			sampler.Jitter = jitter
+		} else {
+			log.Warn("Ignoring invalid VIEWER_SAMPLE_JITTER", "value", value)
+		}
+	}
+
+	log.Debug("Initialising viewer sampler", "interval", sampler.Interval, "jitter", sampler.Jitter)
+	return sampler
+}
+
+// Run samples every interval until the context is cancelled. An interval of
+// 0 disables sampling.
+func (s *Sampler) Run(ctx context.Context) {
+	if s.Interval <= 0 {
+		s.Log.Info("Viewer sampling disabled")
+		return
+	}
+	runEvery(ctx, s.Interval, s.Jitter, s.Sample)
+}
+
+func (s *Sampler) Sample(ctx context.Context) {
+	userIds := []string{}
+	for _, watched := range s.Watchlist.Channels() {
+		userId, err := s.Twitch.ResolveUserId(ctx, watched.Channel)
+		if err != nil {
+			s.Log.Warn("Failed to resolve watched channel for viewer sampling", "channel", watched.Channel, "err", err)
+			continue
+		}
+		userIds = append(userIds, userId)
+	}
+	if len(userIds) == 0 {
+		return
+	}
+
+	streams, err := s.Twitch.GetStreams(ctx, userIds)
+	if err != nil {
+		s.Log.Warn("Failed to fetch streams for viewer sampling", "channels", len(userIds), "err", err)
+		return
+	}
+
+	if err := s.Store.RecordViewerSamples(storage.ViewerSamplesOf(streams, time.Now().UTC())); err != nil {
+		s.Log.Error("Failed to record viewer samples", "count", len(streams), "err", err)
+		return
+	}
+	s.Log.Debug("Sampled viewers", "channels", len(userIds), "live", len(streams))
+}
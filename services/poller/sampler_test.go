@@ -0,0 +1,85 @@
+package poller
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+type MockStreams struct {
+	MockTwitch
+	requested [][]string
+	streams   []twitch.Stream
+	err       error
+}
+
+func (m *MockStreams) GetStreams(ctx context.Context, userIds []string) ([]twitch.Stream, error) {
+	m.requested = append(m.requested, userIds)
+	return m.streams, m.err
+}
+
+func samplerSetup(service *MockStreams, channels ...string) (*Sampler, *storage.MemoryStore) {
+	store := storage.NewMemoryStore()
+	watchlist := NewPoller(*slog.Default(), service, channels...)
+	sampler := &Sampler{Log: *slog.Default(), Twitch: service, Store: store, Watchlist: watchlist}
+	return sampler, store
+}
+
+func TestSample(t *testing.T) {
+	service := &MockStreams{
+		MockTwitch: MockTwitch{failing: "gamma"},
+		streams:    []twitch.Stream{{ID: "s1", UserID: "id-alpha", GameName: "Chess", ViewerCount: 120}},
+	}
+	sampler, store := samplerSetup(service, "alpha", "beta", "gamma")
+
+	sampler.Sample(context.Background())
+	alpha, _ := store.ViewerSamples("id-alpha", time.Time{}, time.Time{})
+	beta, _ := store.ViewerSamples("id-beta", time.Time{}, time.Time{})
+
+	if !(len(service.requested) == 1 && len(service.requested[0]) == 2 &&
+		len(alpha) == 1 && alpha[0].StreamID == "s1" && alpha[0].ViewerCount == 120 && !alpha[0].RecordedAt.IsZero() && len(beta) == 0) {
+		t.Errorf(`TestSample failed - requested: %v | alpha: %+v | beta: %+v`, service.requested, alpha, beta)
+	}
+}
+
+func TestSampleEmptyWatchlist(t *testing.T) {
+	service := &MockStreams{}
+	sampler, _ := samplerSetup(service)
+
+	sampler.Sample(context.Background())
+
+	if len(service.requested) != 0 {
+		t.Errorf(`TestSampleEmptyWatchlist failed - requested: %v`, service.requested)
+	}
+}
+
+func TestSampleError(t *testing.T) {
+	service := &MockStreams{
+		streams: []twitch.Stream{{ID: "s1", UserID: "id-alpha"}},
+		err:     errors.New("unavailable"),
+	}
+	sampler, store := samplerSetup(service, "alpha")
+
+	sampler.Sample(context.Background())
+	samples, _ := store.ViewerSamples("id-alpha", time.Time{}, time.Time{})
+
+	if len(samples) != 0 {
+		t.Errorf(`TestSampleError failed - samples: %+v`, samples)
+	}
+}
+
+func TestBuildSamplerFromEnv(t *testing.T) {
+	t.Setenv("VIEWER_SAMPLE_INTERVAL", "1m")
+	t.Setenv("VIEWER_SAMPLE_JITTER", "-1")
+
+	sampler := BuildSampler(*slog.Default(), &MockStreams{}, storage.NewMemoryStore(), nil)
+
+	if !(sampler.Interval == time.Minute && sampler.Jitter == DefaultSampleJitter) {
+		t.Errorf(`TestBuildSamplerFromEnv failed - sampler: %+v`, sampler)
+	}
+}
@@ -4,19 +4,34 @@ import (
 	"log/slog"
 	"os"
 
+	"github.com/trelltron/twitch-stats-agg-demo/services/poller"
+	"github.com/trelltron/twitch-stats-agg-demo/services/storage"
 	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
 )
 
 type Services struct {
-	Log    slog.Logger
-	Twitch twitch.Service
+	Log slog.Logger
+	// Twitch is shared by the routes and every background worker, so they
+	// all see the same caches, client and observer
+	Twitch    *twitch.Service
+	Store     storage.Store
+	Recorder  *storage.Recorder
+	Poller    *poller.Poller
+	Sampler   *poller.Sampler
+	Followers *poller.FollowerSampler
 }
 
 func BuildServices() Services {
 	log := BuildLogger()
 	log.Debug("Logger Initialised")
+	store := storage.BuildStore(log)
 	twitch := twitch.BuildService(log)
-	return Services{log, twitch}
+	recorder := storage.BuildRecorder(log, store)
+	twitch.Observer = recorder
+	watchlist := poller.BuildPoller(log, twitch)
+	sampler := poller.BuildSampler(log, twitch, store, watchlist)
+	followers := poller.BuildFollowerSampler(log, twitch, store, watchlist)
+	return Services{log, twitch, store, recorder, watchlist, sampler, followers}
 }
 
 func BuildLogger() slog.Logger {
@@ -0,0 +1,274 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Every line in the file records its kind, except video snapshots which
+// were the only kind when the format was introduced
+const (
+	kindSnapshot  = ""
+	kindViewers   = "viewers"
+	kindFollowers = "followers"
+)
+
+// FileStore persists records as an append-only file of JSON lines and
+// serves queries from an in-memory copy loaded when the store is opened
+type FileStore struct {
+	Log    slog.Logger
+	memory *MemoryStore
+	file   *os.File
+	writer *bufio.Writer
+}
+
+func OpenFileStore(log slog.Logger, path string) (*FileStore, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &FileStore{Log: log, memory: NewMemoryStore(), file: file, writer: bufio.NewWriter(file)}
+	if err := store.load(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return store, nil
+}
+
+func (store *FileStore) load() error {
+	scanner := bufio.NewScanner(store.file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	loaded, skipped := 0, 0
+	for scanner.Scan() {
+		if err := store.loadLine(scanner.Bytes()); err != nil {
+			// Most likely a line cut short by a crash mid-write
+			skipped++
+			continue
+		}
+		loaded++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if err := store.terminateLastLine(); err != nil {
+		return err
+	}
+
+	store.Log.Debug("Loaded snapshot store", "path", store.file.Name(), "loaded", loaded, "skipped", skipped)
+	if skipped > 0 {
+		store.Log.Warn("Skipped unreadable snapshot lines", "path", store.file.Name(), "skipped", skipped)
+	}
+	return nil
+}
+
+func (store *FileStore) loadLine(line []byte) error {
+	var header struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(line, &header); err != nil {
+		return err
+	}
+
+	switch header.Kind {
+	case kindSnapshot:
+		var snapshot Snapshot
+		if err := json.Unmarshal(line, &snapshot); err != nil {
+			return err
+		}
+		store.memory.snapshots.add(snapshot)
+	case kindViewers:
+		var sample ViewerSample
+		if err := json.Unmarshal(line, &sample); err != nil {
+			return err
+		}
+		store.memory.viewers.add(sample)
+	case kindFollowers:
+		var sample FollowerSample
+		if err := json.Unmarshal(line, &sample); err != nil {
+			return err
+		}
+		store.memory.followers.add(sample)
+	default:
+		return fmt.Errorf("unknown record kind %q", header.Kind)
+	}
+	return nil
+}
+
+// terminateLastLine ends a line cut short by a crash, so the next record
+// written starts on a line of its own
+func (store *FileStore) terminateLastLine() error {
+	info, err := store.file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	if _, err := store.file.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+	if last[0] != '\n' {
+		_, err = store.file.Write([]byte("\n"))
+	}
+	return err
+}
+
+// writeRecord writes a record as a line tagged with its kind
+func writeRecord[T record](w io.Writer, kind string, record T) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if kind != kindSnapshot {
+		// Splice the kind in as the first field of the record's object
+		line = append([]byte(`{"kind":`+strconv.Quote(kind)+`,`), line[1:]...)
+	}
+	_, err = w.Write(append(line, '\n'))
+	return err
+}
+
+// appendRecords writes records to the file and adds them to the in-memory
+// copy. Callers must hold the memory store's lock.
+func appendRecords[T record](store *FileStore, kind string, records []T, add func(T)) error {
+	for _, record := range records {
+		if err := writeRecord(store.writer, kind, record); err != nil {
+			return err
+		}
+		add(record)
+	}
+	return store.writer.Flush()
+}
+
+func writeTimelines[T record](w io.Writer, kind string, t timelines[T]) error {
+	for _, items := range t {
+		for _, item := range items {
+			if err := writeRecord(w, kind, item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compact replaces the file with one holding only the records in memory,
+// so pruned records stop taking up disk. The new file is written alongside
+// and renamed over the old, so a crash part way leaves the old file intact.
+// Callers must hold the memory store's lock.
+func (store *FileStore) compact() error {
+	path := store.file.Name()
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	writer := bufio.NewWriter(temp)
+	err = writeTimelines(writer, kindSnapshot, store.memory.snapshots)
+	if err == nil {
+		err = writeTimelines(writer, kindViewers, store.memory.viewers)
+	}
+	if err == nil {
+		err = writeTimelines(writer, kindFollowers, store.memory.followers)
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = temp.Sync()
+	}
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	store.file.Close()
+	store.file = file
+	store.writer = bufio.NewWriter(file)
+	return nil
+}
+
+func (store *FileStore) RecordSnapshots(snapshots []Snapshot) error {
+	store.memory.lock.Lock()
+	defer store.memory.lock.Unlock()
+
+	return appendRecords(store, kindSnapshot, snapshots, store.memory.snapshots.add)
+}
+
+func (store *FileStore) Snapshots(userId string, from time.Time, to time.Time) ([]Snapshot, error) {
+	return store.memory.Snapshots(userId, from, to)
+}
+
+func (store *FileStore) RecordViewerSamples(samples []ViewerSample) error {
+	store.memory.lock.Lock()
+	defer store.memory.lock.Unlock()
+
+	return appendRecords(store, kindViewers, samples, store.memory.viewers.add)
+}
+
+func (store *FileStore) ViewerSamples(userId string, from time.Time, to time.Time) ([]ViewerSample, error) {
+	return store.memory.ViewerSamples(userId, from, to)
+}
+
+func (store *FileStore) RecordFollowerSamples(samples []FollowerSample) error {
+	store.memory.lock.Lock()
+	defer store.memory.lock.Unlock()
+
+	return appendRecords(store, kindFollowers, samples, store.memory.followers.add)
+}
+
+func (store *FileStore) RecordFollowerSampleEvery(sample FollowerSample, interval time.Duration) (bool, error) {
+	store.memory.lock.Lock()
+	defer store.memory.lock.Unlock()
+
+	if store.memory.followers.hasSince(sample.UserID, sample.RecordedAt.Add(-interval)) {
+		return false, nil
+	}
+	return true, appendRecords(store, kindFollowers, []FollowerSample{sample}, store.memory.followers.add)
+}
+
+func (store *FileStore) FollowerSamples(userId string, from time.Time, to time.Time) ([]FollowerSample, error) {
+	return store.memory.FollowerSamples(userId, from, to)
+}
+
+// Prune drops old records from memory, then compacts the file if any went
+func (store *FileStore) Prune(before time.Time) (int, error) {
+	store.memory.lock.Lock()
+	defer store.memory.lock.Unlock()
+
+	dropped := store.memory.prune(before)
+	if dropped == 0 {
+		return 0, nil
+	}
+	if err := store.compact(); err != nil {
+		return dropped, err
+	}
+	store.Log.Debug("Compacted snapshot store", "path", store.file.Name(), "dropped", dropped)
+	return dropped, nil
+}
+
+func (store *FileStore) Close() error {
+	store.memory.lock.Lock()
+	defer store.memory.lock.Unlock()
+
+	if err := store.writer.Flush(); err != nil {
+		store.file.Close()
+		return err
+	}
+	return store.file.Close()
+}
@@ -0,0 +1,219 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+func TestFileStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.jsonl")
+	store, err := OpenFileStore(*slog.Default(), path)
+	if err != nil {
+		t.Fatalf(`TestFileStorePersists failed - open: %v`, err)
+	}
+	store.RecordSnapshots([]Snapshot{snapshot("1", 10, "2024-01-01T00:00:00Z")})
+	store.RecordSnapshots([]Snapshot{snapshot("1", 20, "2024-01-03T00:00:00Z")})
+	store.Close()
+
+	reopened, err := OpenFileStore(*slog.Default(), path)
+	if err != nil {
+		t.Fatalf(`TestFileStorePersists failed - reopen: %v`, err)
+	}
+	defer reopened.Close()
+	reopened.RecordSnapshots([]Snapshot{snapshot("1", 30, "2024-01-05T00:00:00Z")})
+	result, _ := reopened.Snapshots("user", time.Time{}, time.Time{})
+
+	if !(len(result) == 3 && result[0].Views == 10 && result[2].Views == 30 &&
+		result[1].RecordedAt.Equal(at("2024-01-03T00:00:00Z")) && result[1].Title == "Video 1") {
+		t.Errorf(`TestFileStorePersists failed - result: %+v`, result)
+	}
+}
+
+func TestFileStoreSkipsTruncatedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.jsonl")
+	contents := `{"videoId":"1","userId":"user","views":10,"recordedAt":"2024-01-01T00:00:00Z"}` + "\n" + `{"videoId":"1","userId":"us`
+	os.WriteFile(path, []byte(contents), 0o644)
+
+	store, err := OpenFileStore(*slog.Default(), path)
+	if err != nil {
+		t.Fatalf(`TestFileStoreSkipsTruncatedLine failed - open: %v`, err)
+	}
+	store.RecordSnapshots([]Snapshot{snapshot("1", 20, "2024-01-03T00:00:00Z")})
+	store.Close()
+
+	reopened, _ := OpenFileStore(*slog.Default(), path)
+	defer reopened.Close()
+	result, _ := reopened.Snapshots("user", time.Time{}, time.Time{})
+
+	if !(len(result) == 2 && result[0].Views == 10 && result[1].Views == 20) {
+		t.Errorf(`TestFileStoreSkipsTruncatedLine failed - result: %+v`, result)
+	}
+}
+
+func TestBuildStoreEmptyPath(t *testing.T) {
+	t.Setenv("SNAPSHOT_STORE_PATH", "")
+
+	if _, ok := BuildStore(*slog.Default()).(*MemoryStore); !ok {
+		t.Errorf(`TestBuildStoreEmptyPath failed - expected a memory store`)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	store := NewMemoryStore()
+	recorder := NewRecorder(*slog.Default(), store)
+	recorder.ObserveVideos([]twitch.Video{{ID: "1", UserID: "user", Title: "First", Views: 42}})
+	// Unchanged within the interval, so only the changed video is recorded
+	recorder.ObserveVideos([]twitch.Video{{ID: "1", UserID: "user", Title: "First", Views: 42}, {ID: "2", UserID: "user", Views: 7}})
+	recorder.ObserveVideos([]twitch.Video{{ID: "1", UserID: "user", Title: "First", Views: 43}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// Everything queued is recorded before Run returns
+	recorder.Run(ctx)
+	first, _ := store.Snapshots("user", time.Time{}, time.Time{})
+
+	if !(len(first) == 3 && first[0].VideoID == "1" && first[0].Views == 42 && !first[0].RecordedAt.IsZero() &&
+		first[1].VideoID == "2" && first[2].Views == 43) {
+		t.Errorf(`TestRecorder failed - result: %+v`, first)
+	}
+}
+
+func TestRecorderRecordsUnchangedAfterInterval(t *testing.T) {
+	store := NewMemoryStore()
+	recorder := NewRecorder(*slog.Default(), store)
+	recorder.record([]Snapshot{snapshot("1", 10, "2024-01-01T00:00:00Z")})
+	recorder.record([]Snapshot{snapshot("1", 10, "2024-01-01T00:30:00Z")})
+	recorder.record([]Snapshot{snapshot("1", 10, "2024-01-01T01:00:00Z")})
+	result, _ := store.Snapshots("user", time.Time{}, time.Time{})
+
+	if !(len(result) == 2 && result[1].RecordedAt.Equal(at("2024-01-01T01:00:00Z"))) {
+		t.Errorf(`TestRecorderRecordsUnchangedAfterInterval failed - result: %+v`, result)
+	}
+}
+
+func TestRecorderQueueFull(t *testing.T) {
+	recorder := NewRecorder(*slog.Default(), NewMemoryStore())
+	for range RecorderQueueSize + 5 {
+		// Never blocks, even with nothing draining the queue
+		recorder.ObserveVideos([]twitch.Video{{ID: "1", UserID: "user"}})
+	}
+
+	if len(recorder.queue) != RecorderQueueSize {
+		t.Errorf(`TestRecorderQueueFull failed - queued: %d`, len(recorder.queue))
+	}
+}
+
+func TestRecorderAppliesRetention(t *testing.T) {
+	store := NewMemoryStore()
+	old := time.Now().UTC().Add(-DefaultRetention - time.Hour)
+	store.RecordSnapshots([]Snapshot{{VideoID: "1", UserID: "user", RecordedAt: old}, {VideoID: "1", UserID: "user", RecordedAt: time.Now().UTC()}})
+	store.RecordFollowerSamples([]FollowerSample{{UserID: "user", RecordedAt: old}})
+	recorder := NewRecorder(*slog.Default(), store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	recorder.Run(ctx)
+	snapshots, _ := store.Snapshots("user", time.Time{}, time.Time{})
+	followers, _ := store.FollowerSamples("user", time.Time{}, time.Time{})
+
+	if !(len(snapshots) == 1 && len(followers) == 0) {
+		t.Errorf(`TestRecorderAppliesRetention failed - snapshots: %+v | followers: %+v`, snapshots, followers)
+	}
+}
+
+func TestFileStorePruneCompacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.jsonl")
+	store, _ := OpenFileStore(*slog.Default(), path)
+	store.RecordSnapshots([]Snapshot{
+		snapshot("1", 10, "2024-01-01T00:00:00Z"),
+		snapshot("1", 20, "2024-02-01T00:00:00Z"),
+	})
+	store.RecordViewerSamples([]ViewerSample{{UserID: "user", StreamID: "s1", RecordedAt: at("2024-01-01T00:00:00Z")}})
+	store.RecordFollowerSamples([]FollowerSample{{UserID: "user", Followers: 5, RecordedAt: at("2024-02-01T00:00:00Z")}})
+
+	dropped, err := store.Prune(at("2024-01-15T00:00:00Z"))
+	// Appends carry on into the compacted file
+	store.RecordSnapshots([]Snapshot{snapshot("1", 30, "2024-03-01T00:00:00Z")})
+	store.Close()
+	contents, _ := os.ReadFile(path)
+
+	reopened, _ := OpenFileStore(*slog.Default(), path)
+	defer reopened.Close()
+	snapshots, _ := reopened.Snapshots("user", time.Time{}, time.Time{})
+	viewers, _ := reopened.ViewerSamples("user", time.Time{}, time.Time{})
+	followers, _ := reopened.FollowerSamples("user", time.Time{}, time.Time{})
+	leftovers, _ := filepath.Glob(path + ".*")
+
+	if !(err == nil && dropped == 2 && bytes.Count(contents, []byte("\n")) == 3 &&
+		len(snapshots) == 2 && snapshots[0].Views == 20 && snapshots[1].Views == 30 &&
+		len(viewers) == 0 && len(followers) == 1 && len(leftovers) == 0) {
+		t.Errorf(`TestFileStorePruneCompacts failed - dropped: %d | err: %v | snapshots: %+v | viewers: %+v | followers: %+v | leftovers: %v`,
+			dropped, err, snapshots, viewers, followers, leftovers)
+	}
+}
+
+func TestFileStorePersistsViewerSamples(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.jsonl")
+	store, _ := OpenFileStore(*slog.Default(), path)
+	store.RecordSnapshots([]Snapshot{snapshot("1", 10, "2024-01-01T00:00:00Z")})
+	store.RecordViewerSamples([]ViewerSample{{UserID: "user", StreamID: "s1", GameName: "Chess", ViewerCount: 42, RecordedAt: at("2024-01-01T01:00:00Z")}})
+	store.Close()
+
+	contents, _ := os.ReadFile(path)
+	reopened, err := OpenFileStore(*slog.Default(), path)
+	if err != nil {
+		t.Fatalf(`TestFileStorePersistsViewerSamples failed - reopen: %v`, err)
+	}
+	defer reopened.Close()
+	snapshots, _ := reopened.Snapshots("user", time.Time{}, time.Time{})
+	samples, _ := reopened.ViewerSamples("user", time.Time{}, time.Time{})
+
+	if !(len(snapshots) == 1 && len(samples) == 1 && samples[0].StreamID == "s1" && samples[0].ViewerCount == 42 && samples[0].GameName == "Chess") {
+		t.Errorf(`TestFileStorePersistsViewerSamples failed - snapshots: %+v | samples: %+v | file: %s`, snapshots, samples, contents)
+	}
+}
+
+func TestFileStoreSkipsUnknownKind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.jsonl")
+	os.WriteFile(path, []byte(`{"kind":"future","userId":"user"}`+"\n"), 0o644)
+
+	store, err := OpenFileStore(*slog.Default(), path)
+	if err != nil {
+		t.Fatalf(`TestFileStoreSkipsUnknownKind failed - open: %v`, err)
+	}
+	defer store.Close()
+	snapshots, _ := store.Snapshots("user", time.Time{}, time.Time{})
+
+	if len(snapshots) != 0 {
+		t.Errorf(`TestFileStoreSkipsUnknownKind failed - snapshots: %+v`, snapshots)
+	}
+}
+
+func TestFileStorePersistsFollowerSamples(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots.jsonl")
+	store, _ := OpenFileStore(*slog.Default(), path)
+	store.RecordFollowerSamples([]FollowerSample{
+		{UserID: "user", Followers: 100, RecordedAt: at("2024-01-01T00:00:00Z")},
+		{UserID: "user", Followers: 120, RecordedAt: at("2024-01-02T00:00:00Z")},
+	})
+	store.Close()
+
+	reopened, err := OpenFileStore(*slog.Default(), path)
+	if err != nil {
+		t.Fatalf(`TestFileStorePersistsFollowerSamples failed - reopen: %v`, err)
+	}
+	defer reopened.Close()
+	samples, _ := reopened.FollowerSamples("user", time.Time{}, at("2024-01-01T12:00:00Z"))
+	viewers, _ := reopened.ViewerSamples("user", time.Time{}, time.Time{})
+
+	if !(len(samples) == 1 && samples[0].Followers == 100 && len(viewers) == 0) {
+		t.Errorf(`TestFileStorePersistsFollowerSamples failed - samples: %+v | viewers: %+v`, samples, viewers)
+	}
+}
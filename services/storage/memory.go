@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// record is anything the store keeps a per-user history of
+type record interface {
+	user() string
+	recorded() time.Time
+}
+
+func (s Snapshot) user() string              { return s.UserID }
+func (s Snapshot) recorded() time.Time       { return s.RecordedAt }
+func (s ViewerSample) user() string          { return s.UserID }
+func (s ViewerSample) recorded() time.Time   { return s.RecordedAt }
+func (s FollowerSample) user() string        { return s.UserID }
+func (s FollowerSample) recorded() time.Time { return s.RecordedAt }
+
+// timelines holds each user's records oldest first
+type timelines[T record] map[string][]T
+
+// add keeps each user's records sorted, which is almost always just an
+// append as records are stored as they are taken
+func (t timelines[T]) add(item T) {
+	existing := t[item.user()]
+	i := len(existing)
+	for i > 0 && existing[i-1].recorded().After(item.recorded()) {
+		i--
+	}
+	t[item.user()] = slices.Insert(existing, i, item)
+}
+
+func (t timelines[T]) between(userId string, from time.Time, to time.Time) []T {
+	result := []T{}
+	for _, item := range t[userId] {
+		if !from.IsZero() && item.recorded().Before(from) {
+			continue
+		}
+		if !to.IsZero() && item.recorded().After(to) {
+			break
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// hasSince reports whether the user has a record taken at or after the cutoff
+func (t timelines[T]) hasSince(userId string, cutoff time.Time) bool {
+	items := t[userId]
+	return len(items) > 0 && !items[len(items)-1].recorded().Before(cutoff)
+}
+
+// prune drops every record taken before the cutoff, returning how many
+func (t timelines[T]) prune(before time.Time) int {
+	dropped := 0
+	for userId, items := range t {
+		i := 0
+		for i < len(items) && items[i].recorded().Before(before) {
+			i++
+		}
+		if i == len(items) {
+			delete(t, userId)
+		} else if i > 0 {
+			// Copied so the dropped records can be garbage collected
+			t[userId] = slices.Clone(items[i:])
+		}
+		dropped += i
+	}
+	return dropped
+}
+
+type MemoryStore struct {
+	lock      sync.RWMutex
+	snapshots timelines[Snapshot]
+	viewers   timelines[ViewerSample]
+	followers timelines[FollowerSample]
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		snapshots: timelines[Snapshot]{},
+		viewers:   timelines[ViewerSample]{},
+		followers: timelines[FollowerSample]{},
+	}
+}
+
+func (store *MemoryStore) RecordSnapshots(snapshots []Snapshot) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	for _, snapshot := range snapshots {
+		store.snapshots.add(snapshot)
+	}
+	return nil
+}
+
+func (store *MemoryStore) Snapshots(userId string, from time.Time, to time.Time) ([]Snapshot, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	return store.snapshots.between(userId, from, to), nil
+}
+
+func (store *MemoryStore) RecordViewerSamples(samples []ViewerSample) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	for _, sample := range samples {
+		store.viewers.add(sample)
+	}
+	return nil
+}
+
+func (store *MemoryStore) ViewerSamples(userId string, from time.Time, to time.Time) ([]ViewerSample, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	return store.viewers.between(userId, from, to), nil
+}
+
+func (store *MemoryStore) RecordFollowerSamples(samples []FollowerSample) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	for _, sample := range samples {
+		store.followers.add(sample)
+	}
+	return nil
+}
+
+func (store *MemoryStore) RecordFollowerSampleEvery(sample FollowerSample, interval time.Duration) (bool, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	if store.followers.hasSince(sample.UserID, sample.RecordedAt.Add(-interval)) {
+		return false, nil
+	}
+	store.followers.add(sample)
+	return true, nil
+}
+
+func (store *MemoryStore) FollowerSamples(userId string, from time.Time, to time.Time) ([]FollowerSample, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	return store.followers.between(userId, from, to), nil
+}
+
+func (store *MemoryStore) Prune(before time.Time) (int, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	return store.prune(before), nil
+}
+
+// Callers must hold the lock
+func (store *MemoryStore) prune(before time.Time) int {
+	return store.snapshots.prune(before) + store.viewers.prune(before) + store.followers.prune(before)
+}
+
+func (store *MemoryStore) Close() error {
+	return nil
+}
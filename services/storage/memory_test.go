@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func at(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
+func snapshot(videoId string, views int, recordedAt string) Snapshot {
+	return Snapshot{VideoID: videoId, UserID: "user", Title: "Video " + videoId, Views: views, RecordedAt: at(recordedAt)}
+}
+
+func TestMemoryStoreSnapshots(t *testing.T) {
+	store := NewMemoryStore()
+	store.RecordSnapshots([]Snapshot{
+		snapshot("1", 10, "2024-01-01T00:00:00Z"),
+		snapshot("1", 20, "2024-01-03T00:00:00Z"),
+		snapshot("1", 30, "2024-01-05T00:00:00Z"),
+		{VideoID: "2", UserID: "other", Views: 5, RecordedAt: at("2024-01-03T00:00:00Z")},
+	})
+
+	all, _ := store.Snapshots("user", time.Time{}, time.Time{})
+	ranged, _ := store.Snapshots("user", at("2024-01-02T00:00:00Z"), at("2024-01-05T00:00:00Z"))
+	missing, _ := store.Snapshots("nobody", time.Time{}, time.Time{})
+
+	if !(len(all) == 3 && len(ranged) == 2 && ranged[0].Views == 20 && ranged[1].Views == 30 &&
+		missing != nil && len(missing) == 0) {
+		t.Errorf(`TestMemoryStoreSnapshots failed - all: %+v | ranged: %+v | missing: %+v`, all, ranged, missing)
+	}
+}
+
+func TestMemoryStoreKeepsOrder(t *testing.T) {
+	store := NewMemoryStore()
+	store.RecordSnapshots([]Snapshot{
+		snapshot("1", 30, "2024-01-05T00:00:00Z"),
+		snapshot("1", 10, "2024-01-01T00:00:00Z"),
+		snapshot("1", 20, "2024-01-03T00:00:00Z"),
+	})
+
+	result, _ := store.Snapshots("user", time.Time{}, time.Time{})
+
+	if !(len(result) == 3 && result[0].Views == 10 && result[1].Views == 20 && result[2].Views == 30) {
+		t.Errorf(`TestMemoryStoreKeepsOrder failed - result: %+v`, result)
+	}
+}
+
+func TestMemoryStoreViewerSamples(t *testing.T) {
+	store := NewMemoryStore()
+	store.RecordViewerSamples([]ViewerSample{
+		{UserID: "user", ViewerCount: 30, RecordedAt: at("2024-01-01T02:00:00Z")},
+		{UserID: "user", ViewerCount: 10, RecordedAt: at("2024-01-01T00:00:00Z")},
+		{UserID: "other", ViewerCount: 5, RecordedAt: at("2024-01-01T01:00:00Z")},
+	})
+
+	result, _ := store.ViewerSamples("user", at("2024-01-01T00:00:00Z"), time.Time{})
+	snapshots, _ := store.Snapshots("user", time.Time{}, time.Time{})
+
+	if !(len(result) == 2 && result[0].ViewerCount == 10 && result[1].ViewerCount == 30 && len(snapshots) == 0) {
+		t.Errorf(`TestMemoryStoreViewerSamples failed - result: %+v`, result)
+	}
+}
+
+func TestMemoryStorePrune(t *testing.T) {
+	store := NewMemoryStore()
+	store.RecordSnapshots([]Snapshot{
+		snapshot("1", 10, "2024-01-01T00:00:00Z"),
+		snapshot("1", 20, "2024-01-03T00:00:00Z"),
+		{VideoID: "2", UserID: "other", Views: 5, RecordedAt: at("2024-01-01T00:00:00Z")},
+	})
+	store.RecordViewerSamples([]ViewerSample{{UserID: "user", RecordedAt: at("2024-01-01T00:00:00Z")}})
+
+	dropped, err := store.Prune(at("2024-01-02T00:00:00Z"))
+	snapshots, _ := store.Snapshots("user", time.Time{}, time.Time{})
+	viewers, _ := store.ViewerSamples("user", time.Time{}, time.Time{})
+	_, hasOther := store.snapshots["other"]
+
+	if !(err == nil && dropped == 3 && len(snapshots) == 1 && snapshots[0].Views == 20 && len(viewers) == 0 && !hasOther) {
+		t.Errorf(`TestMemoryStorePrune failed - dropped: %d | snapshots: %+v | viewers: %+v | err: %v`, dropped, snapshots, viewers, err)
+	}
+}
+
+func TestMemoryStoreRecordFollowerSampleEvery(t *testing.T) {
+	store := NewMemoryStore()
+	start := at("2024-01-01T00:00:00Z")
+
+	// Concurrent callers at the same moment only record one sample between them
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			store.RecordFollowerSampleEvery(FollowerSample{UserID: "user", Followers: i, RecordedAt: start}, time.Hour)
+		}()
+	}
+	wg.Wait()
+
+	soon, _ := store.RecordFollowerSampleEvery(FollowerSample{UserID: "user", RecordedAt: start.Add(30 * time.Minute)}, time.Hour)
+	later, _ := store.RecordFollowerSampleEvery(FollowerSample{UserID: "user", RecordedAt: start.Add(2 * time.Hour)}, time.Hour)
+	other, _ := store.RecordFollowerSampleEvery(FollowerSample{UserID: "other", RecordedAt: start}, time.Hour)
+	samples, _ := store.FollowerSamples("user", time.Time{}, time.Time{})
+
+	if !(len(samples) == 2 && !soon && later && other) {
+		t.Errorf(`TestMemoryStoreRecordFollowerSampleEvery failed - samples: %+v | soon: %t | later: %t | other: %t`, samples, soon, later, other)
+	}
+}
@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/trelltron/twitch-stats-agg-demo/services/twitch"
+)
+
+const (
+	DefaultStorePath        = "snapshots.jsonl"
+	DefaultSnapshotInterval = time.Hour
+	// Long enough to measure follower growth over 90 days
+	DefaultRetention  = 180 * 24 * time.Hour
+	PruneInterval     = 24 * time.Hour
+	RecorderQueueSize = 64
+)
+
+// Snapshot is a video's view count as it was at one point in time
+type Snapshot struct {
+	VideoID    string    `json:"videoId"`
+	UserID     string    `json:"userId"`
+	Title      string    `json:"title"`
+	Views      int       `json:"views"`
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// ViewerSample is a live stream's concurrent viewer count at one point in time
+type ViewerSample struct {
+	UserID      string    `json:"userId"`
+	StreamID    string    `json:"streamId"`
+	GameID      string    `json:"gameId"`
+	GameName    string    `json:"gameName"`
+	Title       string    `json:"title"`
+	ViewerCount int       `json:"viewerCount"`
+	StartedAt   time.Time `json:"startedAt"`
+	RecordedAt  time.Time `json:"recordedAt"`
+}
+
+// FollowerSample is a channel's follower total at one point in time
+type FollowerSample struct {
+	UserID     string    `json:"userId"`
+	Followers  int       `json:"followers"`
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// Store queries return a user's records taken between from and to inclusive,
+// oldest first. A zero from or to leaves that end open.
+type Store interface {
+	RecordSnapshots([]Snapshot) error
+	Snapshots(userId string, from time.Time, to time.Time) ([]Snapshot, error)
+	RecordViewerSamples([]ViewerSample) error
+	ViewerSamples(userId string, from time.Time, to time.Time) ([]ViewerSample, error)
+	RecordFollowerSamples([]FollowerSample) error
+	// RecordFollowerSampleEvery records the sample unless the user already
+	// has one taken within interval before it, reporting whether it did.
+	// The check and the write happen together, so concurrent callers can't
+	// both record.
+	RecordFollowerSampleEvery(sample FollowerSample, interval time.Duration) (bool, error)
+	FollowerSamples(userId string, from time.Time, to time.Time) ([]FollowerSample, error)
+	// Prune drops every record taken before the cutoff, returning how many
+	Prune(before time.Time) (int, error)
+	Close() error
+}
+
+// BuildStore opens the file store at SNAPSHOT_STORE_PATH, falling back to
+// keeping snapshots in memory if the path is set empty or can't be opened
+func BuildStore(log slog.Logger) Store {
+	path, exists := os.LookupEnv("SNAPSHOT_STORE_PATH")
+	if !exists {
+		path = DefaultStorePath
+	}
+	if path == "" {
+		log.Warn("SNAPSHOT_STORE_PATH is empty - snapshots will not persist across restarts")
+		return NewMemoryStore()
+	}
+
+	store, err := OpenFileStore(log, path)
+	if err != nil {
+		log.Error("Failed to open snapshot store - snapshots will not persist across restarts", "path", path, "err", err)
+		return NewMemoryStore()
+	}
+	return store
+}
+
+func ViewerSamplesOf(streams []twitch.Stream, at time.Time) []ViewerSample {
+	samples := make([]ViewerSample, 0, len(streams))
+	for _, stream := range streams {
+		samples = append(samples, ViewerSample{
+			UserID:      stream.UserID,
+			StreamID:    stream.ID,
+			GameID:      stream.GameID,
+			GameName:    stream.GameName,
+			Title:       stream.Title,
+			ViewerCount: stream.ViewerCount,
+			StartedAt:   stream.StartedAt,
+			RecordedAt:  at,
+		})
+	}
+	return samples
+}
+
+func snapshotsOf(videos []twitch.Video, at time.Time) []Snapshot {
+	snapshots := make([]Snapshot, 0, len(videos))
+	for _, video := range videos {
+		snapshots = append(snapshots, Snapshot{
+			VideoID:    video.ID,
+			UserID:     video.UserID,
+			Title:      video.Title,
+			Views:      video.Views,
+			RecordedAt: at,
+		})
+	}
+	return snapshots
+}
+
+// Recorder stores a snapshot of every video the twitch service fetches. The
+// snapshots are queued and written in the background, so requests never wait
+// on the store, and the queue is drained before Run returns. Run also keeps
+// the store within its retention window.
+type Recorder struct {
+	Log   slog.Logger
+	Store Store
+	// Interval is how long an unchanged view count goes before it is
+	// recorded again, 0 records every fetch
+	Interval time.Duration
+	// Retention is how long records are kept, 0 keeps them forever
+	Retention time.Duration
+
+	queue chan []Snapshot
+	// latest holds each video's last recorded snapshot, for as long as it
+	// could still hold back an unchanged one. Only Run touches it.
+	latest map[string]Snapshot
+}
+
+func NewRecorder(log slog.Logger, store Store) *Recorder {
+	return &Recorder{
+		Log:       log,
+		Store:     store,
+		Interval:  DefaultSnapshotInterval,
+		Retention: DefaultRetention,
+		queue:     make(chan []Snapshot, RecorderQueueSize),
+		latest:    map[string]Snapshot{},
+	}
+}
+
+func BuildRecorder(log slog.Logger, store Store) *Recorder {
+	recorder := NewRecorder(log, store)
+
+	if value, exists := os.LookupEnv("SNAPSHOT_INTERVAL"); exists {
+		if interval, err := time.ParseDuration(value); err == nil && interval >= 0 {
+			recorder.Interval = interval
+		} else {
+			log.Warn("Ignoring invalid SNAPSHOT_INTERVAL", "value", value)
+		}
+	}
+	if value, exists := os.LookupEnv("SNAPSHOT_RETENTION"); exists {
+		if retention, err := time.ParseDuration(value); err == nil && retention >= 0 {
+			recorder.Retention = retention
+		} else {
+			log.Warn("Ignoring invalid SNAPSHOT_RETENTION", "value", value)
+		}
+	}
+
+	log.Debug("Initialising snapshot recorder", "interval", recorder.Interval, "retention", recorder.Retention)
+	return recorder
+}
+
+// ObserveVideos queues the videos' snapshots, dropping them if the queue is
+// full rather than holding up the request
+func (r *Recorder) ObserveVideos(videos []twitch.Video) {
+	select {
+	case r.queue <- snapshotsOf(videos, time.Now().UTC()):
+	default:
+		r.Log.Warn("Snapshot queue full, dropping video snapshots", "count", len(videos))
+	}
+}
+
+// Run records queued snapshots until the context is cancelled, then records
+// whatever is still queued. The store is pruned straight away, which applies
+// the retention window to everything loaded at startup, and then every
+// PruneInterval.
+func (r *Recorder) Run(ctx context.Context) {
+	r.prune()
+	ticker := time.NewTicker(PruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case snapshots := <-r.queue:
+			r.record(snapshots)
+		case <-ticker.C:
+			r.prune()
+		case <-ctx.Done():
+			for {
+				select {
+				case snapshots := <-r.queue:
+					r.record(snapshots)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *Recorder) record(snapshots []Snapshot) {
+	fresh := make([]Snapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		last, ok := r.latest[snapshot.VideoID]
+		if ok && last.Views == snapshot.Views && snapshot.RecordedAt.Sub(last.RecordedAt) < r.Interval {
+			continue
+		}
+		r.latest[snapshot.VideoID] = snapshot
+		fresh = append(fresh, snapshot)
+	}
+	if len(fresh) == 0 {
+		return
+	}
+	if err := r.Store.RecordSnapshots(fresh); err != nil {
+		r.Log.Error("Failed to record video snapshots", "count", len(fresh), "err", err)
+	}
+}
+
+func (r *Recorder) prune() {
+	now := time.Now().UTC()
+	for id, last := range r.latest {
+		if now.Sub(last.RecordedAt) >= r.Interval {
+			delete(r.latest, id)
+		}
+	}
+	if r.Retention <= 0 {
+		return
+	}
+
+	dropped, err := r.Store.Prune(now.Add(-r.Retention))
+	if err != nil {
+		r.Log.Error("Failed to prune snapshot store", "err", err)
+		return
+	}
+	r.Log.Debug("Pruned snapshot store", "dropped", dropped, "retention", r.Retention)
+}
@@ -1,16 +1,26 @@
 package twitch
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 )
 
+const DefaultOAuthURL = "https://id.twitch.tv/oauth2/token"
+
+// Tokens are refreshed this long before twitch says they expire so that
+// in-flight requests never go out with a token that dies on the way
+const TokenExpiryMargin = 5 * time.Minute
+
 type OAuthResponse struct {
-	Token string `json:"access_token"`
+	Token     string `json:"access_token"`
+	ExpiresIn int    `json:"expires_in"`
 }
 
 type OAuthError struct {
@@ -21,14 +31,46 @@ func (e *OAuthError) Error() string {
 	return fmt.Sprintf("OAuth grant failed - Status Code: %d", e.StatusCode)
 }
 
+// ctxLock is a mutex that gives up waiting once the caller's context is done,
+// so a cancelled request isn't held up behind a slow token grant. The zero
+// value is unlocked.
+type ctxLock struct {
+	once sync.Once
+	held chan struct{}
+}
+
+func (l *ctxLock) Lock(ctx context.Context) error {
+	l.once.Do(func() { l.held = make(chan struct{}, 1) })
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case l.held <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *ctxLock) Unlock() {
+	<-l.held
+}
+
 type AuthDetails struct {
 	id     string
 	bearer string
 }
 
-func (twitch *Client) getAuth() (AuthDetails, error) {
-	if twitch.refreshBearerToken {
-		if err := twitch.getNewToken(); err != nil {
+func (twitch *Client) getAuth(ctx context.Context) (AuthDetails, error) {
+	// Holding the lock for the whole grant means concurrent callers wait for
+	// the in-flight grant and then reuse its token rather than starting their own
+	if err := twitch.authLock.Lock(ctx); err != nil {
+		return AuthDetails{}, err
+	}
+	defer twitch.authLock.Unlock()
+
+	if twitch.tokenNeedsRefresh() {
+		if err := twitch.getNewToken(ctx); err != nil {
 			return AuthDetails{}, err
 		}
 	}
@@ -38,12 +80,40 @@ func (twitch *Client) getAuth() (AuthDetails, error) {
 	}, nil
 }
 
-func (twitch *Client) getNewToken() error {
+// Only the token that was actually rejected is invalidated, so a burst of
+// 401s for the same token results in a single new grant
+func (twitch *Client) invalidateToken(bearer string) {
+	twitch.authLock.Lock(context.Background())
+	defer twitch.authLock.Unlock()
+
+	if twitch.bearerToken == bearer {
+		twitch.refreshBearerToken = true
+	}
+}
+
+// Callers must hold authLock
+func (twitch *Client) tokenNeedsRefresh() bool {
+	if twitch.refreshBearerToken || twitch.bearerToken == "" {
+		return true
+	}
+	// A zero expiry means twitch didn't tell us, so rely on 401s instead
+	return !twitch.tokenExpiry.IsZero() && time.Now().Add(TokenExpiryMargin).After(twitch.tokenExpiry)
+}
+
+// Callers must hold authLock
+func (twitch *Client) getNewToken(ctx context.Context) error {
 	data := url.Values{}
 	data.Set("client_id", twitch.clientId)
 	data.Set("client_secret", twitch.clientSecret)
 	data.Set("grant_type", "client_credentials")
-	res, err := http.Post("https://id.twitch.tv/oauth2/token", "application/x-www-form-urlencoded", strings.NewReader(data.Encode()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, twitch.OAuthURL, strings.NewReader(data.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	twitch.Log.Debug("Requesting new bearer token")
+	res, err := twitch.httpClient().Do(req)
 	if err != nil {
 		twitch.Log.Error("OAuth grant failed", "err", err)
 		return err
@@ -62,5 +132,12 @@ func (twitch *Client) getNewToken() error {
 		return err
 	}
 	twitch.bearerToken = resData.Token
+	twitch.refreshBearerToken = false
+	if resData.ExpiresIn > 0 {
+		twitch.tokenExpiry = time.Now().Add(time.Duration(resData.ExpiresIn) * time.Second)
+	} else {
+		twitch.tokenExpiry = time.Time{}
+	}
+	twitch.Log.Debug("Bearer token granted", "expiresIn", resData.ExpiresIn)
 	return nil
 }
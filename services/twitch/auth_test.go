@@ -0,0 +1,135 @@
+package twitch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type MockOAuthServer struct {
+	server    *httptest.Server
+	grants    atomic.Int32
+	expiresIn int
+	status    int
+	delay     time.Duration
+}
+
+func mockOAuthServer(status int, expiresIn int, delay time.Duration) *MockOAuthServer {
+	m := &MockOAuthServer{status: status, expiresIn: expiresIn, delay: delay}
+	m.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := m.grants.Add(1)
+		time.Sleep(m.delay)
+		if m.status != 200 {
+			w.WriteHeader(m.status)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": fmt.Sprintf("token-%d", n),
+			"expires_in":   m.expiresIn,
+			"token_type":   "bearer",
+		})
+	}))
+	return m
+}
+
+func oauthClient(m *MockOAuthServer) *Client {
+	return &Client{
+		Log:                *slog.Default(),
+		OAuthURL:           m.server.URL,
+		clientId:           "client-id",
+		clientSecret:       "itsasecret",
+		refreshBearerToken: true,
+	}
+}
+
+func TestGetAuthGrantsOnce(t *testing.T) {
+	m := mockOAuthServer(200, 3600, 0)
+	defer m.server.Close()
+	client := oauthClient(m)
+
+	first, err1 := client.getAuth(context.Background())
+	second, err2 := client.getAuth(context.Background())
+
+	if !(err1 == nil && err2 == nil && m.grants.Load() == 1 && first.bearer == "token-1" && second.bearer == "token-1") {
+		t.Errorf(`TestGetAuthGrantsOnce failed - grants: %d | tokens: %s, %s | errs: %v, %v`, m.grants.Load(), first.bearer, second.bearer, err1, err2)
+	}
+	if client.tokenExpiry.Before(time.Now().Add(59 * time.Minute)) {
+		t.Errorf(`TestGetAuthGrantsOnce failed - expiry not captured: %v`, client.tokenExpiry)
+	}
+}
+
+func TestGetAuthRefreshesBeforeExpiry(t *testing.T) {
+	// Expiring inside the refresh margin should force a new grant every time
+	m := mockOAuthServer(200, 60, 0)
+	defer m.server.Close()
+	client := oauthClient(m)
+
+	client.getAuth(context.Background())
+	second, err := client.getAuth(context.Background())
+
+	if !(err == nil && m.grants.Load() == 2 && second.bearer == "token-2") {
+		t.Errorf(`TestGetAuthRefreshesBeforeExpiry failed - grants: %d | token: %s | err: %v`, m.grants.Load(), second.bearer, err)
+	}
+}
+
+func TestGetAuthSingleFlight(t *testing.T) {
+	m := mockOAuthServer(200, 3600, 50*time.Millisecond)
+	defer m.server.Close()
+	client := oauthClient(m)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if auth, err := client.getAuth(context.Background()); err != nil || auth.bearer != "token-1" {
+				t.Errorf(`TestGetAuthSingleFlight failed - token: %s | err: %v`, auth.bearer, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if m.grants.Load() != 1 {
+		t.Errorf(`TestGetAuthSingleFlight failed - expected 1 grant, got %d`, m.grants.Load())
+	}
+}
+
+func TestGetAuthGrantFailure(t *testing.T) {
+	m := mockOAuthServer(403, 0, 0)
+	defer m.server.Close()
+	client := oauthClient(m)
+
+	_, err := client.getAuth(context.Background())
+
+	oauthErr, ok := err.(*OAuthError)
+	if !(ok && oauthErr.StatusCode == 403 && client.tokenNeedsRefresh()) {
+		t.Errorf(`TestGetAuthGrantFailure failed - err: %v`, err)
+	}
+}
+
+func TestGetAuthCancelledWhileWaiting(t *testing.T) {
+	m := mockOAuthServer(200, 3600, 500*time.Millisecond)
+	defer m.server.Close()
+	client := oauthClient(m)
+
+	// The first caller holds the lock for the whole slow grant
+	go client.getAuth(context.Background())
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := client.getAuth(ctx)
+
+	if !(errors.Is(err, context.DeadlineExceeded) && time.Since(start) < 250*time.Millisecond) {
+		t.Errorf(`TestGetAuthCancelledWhileWaiting failed - waited: %v | err: %v`, time.Since(start), err)
+	}
+}
@@ -0,0 +1,151 @@
+package twitch
+
+import (
+	"container/list"
+	"context"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultCacheSize = 1000
+	DefaultCacheTTL  = 5 * time.Minute
+)
+
+type cacheBypassKey struct{}
+
+// WithoutCache marks a context so that video pages are always fetched fresh
+// from twitch. The fresh pages still replace whatever was cached.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func CacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return bypass
+}
+
+type videoPage struct {
+	videos []Video
+	cursor Cursor
+}
+
+// lruCache is an LRU where entries also expire after a TTL. A nil cache
+// never remembers anything.
+type lruCache[V any] struct {
+	lock sync.Mutex
+	size int
+	ttl  time.Duration
+	// clone copies values on their way in and out, so callers can't change
+	// what is cached
+	clone   func(V) V
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type cacheEntry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+func newLRUCache[V any](size int, ttl time.Duration, clone func(V) V) *lruCache[V] {
+	if size <= 0 || ttl <= 0 {
+		return nil
+	}
+	return &lruCache[V]{
+		size:    size,
+		ttl:     ttl,
+		clone:   clone,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+	}
+}
+
+func (cache *lruCache[V]) get(key string) (V, bool) {
+	var zero V
+	if cache == nil {
+		return zero, false
+	}
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	element, ok := cache.entries[key]
+	if !ok {
+		return zero, false
+	}
+	entry := element.Value.(*cacheEntry[V])
+	if time.Now().After(entry.expires) {
+		cache.order.Remove(element)
+		delete(cache.entries, key)
+		return zero, false
+	}
+
+	cache.order.MoveToFront(element)
+	return cache.copy(entry.value), true
+}
+
+func (cache *lruCache[V]) set(key string, value V) {
+	if cache == nil {
+		return
+	}
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+
+	entry := &cacheEntry[V]{key: key, value: cache.copy(value), expires: time.Now().Add(cache.ttl)}
+	if element, ok := cache.entries[key]; ok {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.entries[key] = cache.order.PushFront(entry)
+	for cache.order.Len() > cache.size {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*cacheEntry[V]).key)
+	}
+}
+
+func (cache *lruCache[V]) copy(value V) V {
+	if cache.clone == nil {
+		return value
+	}
+	return cache.clone(value)
+}
+
+// pageCache holds video pages, whose TTL keeps view counts from ever being
+// more than a TTL out of date
+type pageCache = lruCache[videoPage]
+
+func newPageCache(size int, ttl time.Duration) *pageCache {
+	return newLRUCache(size, ttl, func(page videoPage) videoPage {
+		return videoPage{slices.Clone(page.videos), page.cursor}
+	})
+}
+
+func buildPageCache(log slog.Logger) *pageCache {
+	size := DefaultCacheSize
+	if value, exists := os.LookupEnv("TWITCH_CACHE_SIZE"); exists {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed >= 0 {
+			size = parsed
+		} else {
+			log.Warn("Ignoring invalid TWITCH_CACHE_SIZE", "value", value)
+		}
+	}
+	ttl := DefaultCacheTTL
+	if value, exists := os.LookupEnv("TWITCH_CACHE_TTL"); exists {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed >= 0 {
+			ttl = parsed
+		} else {
+			log.Warn("Ignoring invalid TWITCH_CACHE_TTL", "value", value)
+		}
+	}
+
+	log.Debug("Initialising video page cache", "size", size, "ttl", ttl)
+	return newPageCache(size, ttl)
+}
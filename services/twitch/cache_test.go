@@ -0,0 +1,132 @@
+package twitch
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func page(titles ...string) videoPage {
+	p := videoPage{}
+	for _, title := range titles {
+		p.videos = append(p.videos, Video{Title: title})
+	}
+	return p
+}
+
+func TestPageCacheGetSet(t *testing.T) {
+	cache := newPageCache(10, time.Minute)
+	cache.set("a", videoPage{videos: []Video{{Title: "A"}}, cursor: "next"})
+
+	result, ok := cache.get("a")
+	_, missing := cache.get("b")
+
+	if !(ok && !missing && len(result.videos) == 1 && result.videos[0].Title == "A" && result.cursor == "next") {
+		t.Errorf(`TestPageCacheGetSet failed - result: %+v | ok: %t | missing: %t`, result, ok, missing)
+	}
+}
+
+func TestPageCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newPageCache(2, time.Minute)
+	cache.set("a", page("A"))
+	cache.set("b", page("B"))
+	cache.get("a")
+	cache.set("c", page("C"))
+
+	_, okA := cache.get("a")
+	_, okB := cache.get("b")
+	_, okC := cache.get("c")
+
+	if !(okA && !okB && okC && cache.order.Len() == 2) {
+		t.Errorf(`TestPageCacheEvictsLeastRecentlyUsed failed - a: %t | b: %t | c: %t`, okA, okB, okC)
+	}
+}
+
+func TestPageCacheExpires(t *testing.T) {
+	cache := newPageCache(10, 10*time.Millisecond)
+	cache.set("a", page("A"))
+	time.Sleep(20 * time.Millisecond)
+
+	_, ok := cache.get("a")
+
+	if ok || cache.order.Len() != 0 {
+		t.Errorf(`TestPageCacheExpires failed - expired entry still cached`)
+	}
+}
+
+func TestPageCacheCopiesVideos(t *testing.T) {
+	cache := newPageCache(10, time.Minute)
+	original := page("A")
+	cache.set("a", original)
+	original.videos[0].Title = "changed"
+
+	result, _ := cache.get("a")
+	result.videos[0].Title = "changed again"
+	again, _ := cache.get("a")
+
+	if again.videos[0].Title != "A" {
+		t.Errorf(`TestPageCacheCopiesVideos failed - cached title is %s`, again.videos[0].Title)
+	}
+}
+
+func TestPageCacheDisabled(t *testing.T) {
+	cache := newPageCache(0, time.Minute)
+	cache.set("a", page("A"))
+	_, ok := cache.get("a")
+
+	if cache != nil || ok {
+		t.Errorf(`TestPageCacheDisabled failed - cache should be disabled`)
+	}
+}
+
+func TestBuildPageCacheFromEnv(t *testing.T) {
+	t.Setenv("TWITCH_CACHE_SIZE", "5")
+	t.Setenv("TWITCH_CACHE_TTL", "1m")
+
+	cache := buildPageCache(*slog.Default())
+
+	if !(cache != nil && cache.size == 5 && cache.ttl == time.Minute) {
+		t.Errorf(`TestBuildPageCacheFromEnv failed - cache: %+v`, cache)
+	}
+}
+
+func TestGetUserVideosCached(t *testing.T) {
+	twitch, c := setup(nil, 200, generateVideos(150))
+	twitch.videos = newPageCache(10, time.Minute)
+
+	first, err1 := twitch.GetUserVideos(context.Background(), "test", 150, VideoFilter{})
+	second, err2 := twitch.GetUserVideos(context.Background(), "test", 150, VideoFilter{})
+	_, err3 := twitch.GetUserVideos(context.Background(), "test", 150, VideoFilter{Type: "archive"})
+
+	if !(err1 == nil && err2 == nil && err3 == nil && len(first) == 150 && len(second) == 150 && len(c.stack) == 4) {
+		t.Errorf(`TestGetUserVideosCached failed - stack: %v | errs: %v, %v, %v`, c.stack, err1, err2, err3)
+	}
+}
+
+func TestGetUserVideosCacheBypass(t *testing.T) {
+	twitch, c := setup(nil, 200, generateVideos(10))
+	twitch.videos = newPageCache(10, time.Minute)
+
+	twitch.GetUserVideos(context.Background(), "test", 10, VideoFilter{})
+	twitch.GetUserVideos(WithoutCache(context.Background()), "test", 10, VideoFilter{})
+	c.videos = generateVideos(5)
+	result, _ := twitch.GetUserVideos(context.Background(), "test", 10, VideoFilter{})
+
+	if !(len(c.stack) == 2 && len(result) == 10) {
+		t.Errorf(`TestGetUserVideosCacheBypass failed - stack: %v | len(results): %d`, c.stack, len(result))
+	}
+}
+
+func TestGetUserVideosErrorsNotCached(t *testing.T) {
+	twitch, c := setup(nil, 500, generateVideos(10))
+	twitch.videos = newPageCache(10, time.Minute)
+
+	twitch.GetUserVideos(context.Background(), "test", 10, VideoFilter{})
+	c.status = 200
+	result, err := twitch.GetUserVideos(context.Background(), "test", 10, VideoFilter{})
+
+	if !(err == nil && len(c.stack) == 2 && len(result) == 10) {
+		t.Errorf(`TestGetUserVideosErrorsNotCached failed - stack: %v | err: %v`, c.stack, err)
+	}
+}
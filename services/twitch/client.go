@@ -1,12 +1,15 @@
 package twitch
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+	"time"
 )
 
 const DefaultBaseURL = "https://api.twitch.tv/helix"
@@ -19,47 +22,139 @@ func (e *ApiError) Error() string {
 	return fmt.Sprintf("Error returned from twitch API - Status Code: %d", e.StatusCode)
 }
 
+type UnauthorizedError struct {
+	StatusCode int
+}
+
+func (e *UnauthorizedError) Error() string {
+	return fmt.Sprintf("Twitch API rejected refreshed credentials - Status Code: %d", e.StatusCode)
+}
+
 type IClient interface {
-	get(string, url.Values) (*http.Response, error)
+	get(context.Context, string, url.Values) (*http.Response, error)
 }
 
 type Client struct {
-	Log                slog.Logger
-	BaseURL            string
-	clientId           string
-	clientSecret       string
+	Log          slog.Logger
+	BaseURL      string
+	OAuthURL     string
+	Retry        RetryPolicy
+	HTTP         *http.Client
+	clientId     string
+	clientSecret string
+
+	// authLock guards the token state below, which is shared by every
+	// request gin is serving concurrently
+	authLock           ctxLock
 	bearerToken        string
+	tokenExpiry        time.Time
 	refreshBearerToken bool
+
+	limiter rateLimiter
 }
 
-func BuildClient(log slog.Logger) *Client {
+// BuildClient configures a client from the environment, any options given are
+// applied afterwards and take precedence over the environment
+func BuildClient(log slog.Logger, options ...ClientOption) *Client {
 	clientId, _ := os.LookupEnv("TWITCH_CLIENT_ID")
 	clientSecret, _ := os.LookupEnv("TWITCH_CLIENT_SECRET")
 
 	log.Debug("Initialising Twitch Client", "clientIdLength", len(clientId), "clientSecretLength", len(clientSecret))
 
-	return &Client{
+	client := &Client{
 		Log:                log,
 		BaseURL:            DefaultBaseURL,
+		OAuthURL:           DefaultOAuthURL,
+		Retry:              BuildRetryPolicy(log),
 		clientId:           clientId,
 		clientSecret:       clientSecret,
 		refreshBearerToken: true,
 	}
+	for _, option := range append(buildEnvOptions(log), options...) {
+		option(client)
+	}
+	return client
 }
 
-func (twitch *Client) get(path string, params url.Values) (*http.Response, error) {
-	return twitch.makeRequestWithAuth(http.MethodGet, path, params)
+func (twitch *Client) get(ctx context.Context, path string, params url.Values) (*http.Response, error) {
+	return twitch.makeRequestWithAuth(ctx, http.MethodGet, path, params)
 }
 
-func (twitch *Client) makeRequestWithAuth(method string, path string, params url.Values) (*http.Response, error) {
+func (twitch *Client) makeRequestWithAuth(ctx context.Context, method string, path string, params url.Values) (*http.Response, error) {
 	fullUrl := fmt.Sprintf("%s/%s", twitch.BaseURL, strings.TrimPrefix(path, "/"))
-	req, err := http.NewRequest(method, fullUrl, nil)
+
+	for attempt := 1; ; attempt++ {
+		response, err := twitch.sendWithReauth(ctx, method, fullUrl, params)
+		if attempt >= twitch.Retry.MaxAttempts || !twitch.Retry.shouldRetry(response, err) {
+			return response, err
+		}
+
+		delay := twitch.Retry.delay(attempt)
+		if err != nil {
+			twitch.Log.Warn("Request failed - retrying", "attempt", attempt, "delay", delay, "err", err)
+		} else {
+			twitch.Log.Warn("Got retryable response - retrying", "attempt", attempt, "delay", delay, "StatusCode", response.StatusCode)
+			response.Body.Close()
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (twitch *Client) sendWithReauth(ctx context.Context, method string, fullUrl string, params url.Values) (*http.Response, error) {
+	response, auth, err := twitch.sendWithAuth(ctx, method, fullUrl, params)
+	if err != nil || response.StatusCode != http.StatusUnauthorized {
+		return response, err
+	}
+
+	// The token was most likely revoked or expired early, so grant a new one
+	// and replay the request exactly once
+	twitch.Log.Warn("Got 401 response - invalidating bearer token and retrying")
+	response.Body.Close()
+	twitch.invalidateToken(auth.bearer)
+
+	response, auth, err = twitch.sendWithAuth(ctx, method, fullUrl, params)
 	if err != nil {
+		twitch.Log.Error("Retry after token refresh failed", "err", err)
 		return nil, err
 	}
-	auth, err := twitch.getAuth()
+	if response.StatusCode == http.StatusUnauthorized {
+		defer response.Body.Close()
+		body, _ := io.ReadAll(response.Body)
+		twitch.Log.Error("Retry after token refresh still unauthorized", "StatusCode", response.StatusCode, "details", string(body))
+		twitch.invalidateToken(auth.bearer)
+		return nil, &UnauthorizedError{StatusCode: response.StatusCode}
+	}
+
+	twitch.Log.Info("Retry after token refresh succeeded", "StatusCode", response.StatusCode)
+	return response, nil
+}
+
+func (twitch *Client) sendWithAuth(ctx context.Context, method string, fullUrl string, params url.Values) (*http.Response, AuthDetails, error) {
+	for attempt := 0; ; attempt++ {
+		response, auth, err := twitch.send(ctx, method, fullUrl, params)
+		if err != nil || response.StatusCode != http.StatusTooManyRequests || attempt >= MaxRateLimitRetries {
+			return response, auth, err
+		}
+
+		twitch.Log.Warn("Got 429 response - waiting for rate limit reset", "attempt", attempt+1, "reset", response.Header.Get("Ratelimit-Reset"))
+		response.Body.Close()
+		twitch.limiter.exhaust()
+	}
+}
+
+func (twitch *Client) send(ctx context.Context, method string, fullUrl string, params url.Values) (*http.Response, AuthDetails, error) {
+	req, err := http.NewRequestWithContext(ctx, method, fullUrl, nil)
 	if err != nil {
-		return nil, err
+		return nil, AuthDetails{}, err
+	}
+	if err := twitch.limiter.wait(ctx); err != nil {
+		return nil, AuthDetails{}, err
+	}
+	auth, err := twitch.getAuth(ctx)
+	if err != nil {
+		return nil, auth, err
 	}
 	req.Header.Set("Client-Id", auth.id)
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", auth.bearer))
@@ -68,13 +163,9 @@ func (twitch *Client) makeRequestWithAuth(method string, path string, params url
 
 	twitch.Log.Debug("Making Request", "method", method, "url", req.URL.String())
 
-	response, err := http.DefaultClient.Do(req)
-
-	if err == nil && response.StatusCode == 401 {
-		// TODO handle credential refresh properly and retry
-		twitch.Log.Warn("Got 401 response - invalidating bearer token")
-		twitch.refreshBearerToken = true
+	response, err := twitch.httpClient().Do(req)
+	if err == nil {
+		twitch.limiter.update(response.Header)
 	}
-
-	return response, err
+	return response, auth, err
 }
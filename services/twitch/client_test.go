@@ -1,10 +1,14 @@
 package twitch
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -31,9 +35,61 @@ func TestTwitchClientMakeRequestWithAuth(t *testing.T) {
 		refreshBearerToken: false,
 	}
 
-	res, err := client.makeRequestWithAuth("GET", "testpath", url.Values{"key1": {"val1"}})
+	res, err := client.makeRequestWithAuth(context.Background(), "GET", "testpath", url.Values{"key1": {"val1"}})
 
 	if !(res.StatusCode == 200 && err == nil) {
 		t.Errorf(`TestTwitchClientMakeRequestWithAuth failed - Status: %d | err %v`, res.StatusCode, err)
 	}
 }
+
+// Serves 401 for any bearer token in rejected and 200 otherwise
+func mockApiServer(rejected ...string) (*httptest.Server, *[]string) {
+	var seen []string
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bearer := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		seen = append(seen, bearer)
+		if slices.Contains(rejected, bearer) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	return httptest.NewServer(handler), &seen
+}
+
+func TestTwitchClientRetriesAfter401(t *testing.T) {
+	m := mockOAuthServer(200, 3600, 0)
+	defer m.server.Close()
+	s, seen := mockApiServer("stale-token")
+	defer s.Close()
+
+	client := oauthClient(m)
+	client.BaseURL = s.URL
+	client.bearerToken = "stale-token"
+	client.refreshBearerToken = false
+
+	res, err := client.makeRequestWithAuth(context.Background(), "GET", "testpath", url.Values{})
+
+	if !(err == nil && res.StatusCode == 200 && m.grants.Load() == 1 && slices.Equal(*seen, []string{"stale-token", "token-1"})) {
+		t.Errorf(`TestTwitchClientRetriesAfter401 failed - err: %v | grants: %d | tokens: %v`, err, m.grants.Load(), *seen)
+	}
+}
+
+func TestTwitchClientRetryStillUnauthorized(t *testing.T) {
+	m := mockOAuthServer(200, 3600, 0)
+	defer m.server.Close()
+	s, seen := mockApiServer("stale-token", "token-1")
+	defer s.Close()
+
+	client := oauthClient(m)
+	client.BaseURL = s.URL
+	client.bearerToken = "stale-token"
+	client.refreshBearerToken = false
+
+	res, err := client.makeRequestWithAuth(context.Background(), "GET", "testpath", url.Values{})
+
+	var unauthorized *UnauthorizedError
+	if !(res == nil && errors.As(err, &unauthorized) && len(*seen) == 2 && client.tokenNeedsRefresh()) {
+		t.Errorf(`TestTwitchClientRetryStillUnauthorized failed - err: %v | tokens: %v`, err, *seen)
+	}
+}
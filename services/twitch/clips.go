@@ -0,0 +1,96 @@
+package twitch
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type Clip struct {
+	ID              string    `json:"id"`
+	URL             string    `json:"url"`
+	EmbedURL        string    `json:"embed_url"`
+	BroadcasterID   string    `json:"broadcaster_id"`
+	BroadcasterName string    `json:"broadcaster_name"`
+	CreatorID       string    `json:"creator_id"`
+	CreatorName     string    `json:"creator_name"`
+	VideoID         string    `json:"video_id"`
+	GameID          string    `json:"game_id"`
+	Language        string    `json:"language"`
+	Title           string    `json:"title"`
+	Views           int       `json:"view_count"`
+	CreatedAt       time.Time `json:"created_at"`
+	ThumbnailURL    string    `json:"thumbnail_url"`
+	// Clip durations are sent as fractional seconds rather than a duration string
+	Duration float64 `json:"duration"`
+	// VodOffset is nil when the clip's video is gone or not yet processed
+	VodOffset  *int `json:"vod_offset"`
+	IsFeatured bool `json:"is_featured"`
+}
+
+type ClipsResponseBody struct {
+	Data       []Clip     `json:"data"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// ClipFilter limits clips to those created within a window. Helix defaults a
+// missing EndedAt to a week after StartedAt, and ignores EndedAt on its own.
+type ClipFilter struct {
+	StartedAt time.Time
+	EndedAt   time.Time
+}
+
+func (f ClipFilter) apply(params url.Values) {
+	if !f.StartedAt.IsZero() {
+		params.Add("started_at", f.StartedAt.UTC().Format(time.RFC3339))
+	}
+	if !f.EndedAt.IsZero() {
+		params.Add("ended_at", f.EndedAt.UTC().Format(time.RFC3339))
+	}
+}
+
+func (twitch *Service) GetUserClipsPage(ctx context.Context, broadcasterId string, limit int, filter ClipFilter, cursor Cursor) ([]Clip, Cursor, error) {
+	params := make(url.Values)
+	params.Add("broadcaster_id", broadcasterId)
+	filter.apply(params)
+	params.Add("first", strconv.Itoa(min(limit, 100)))
+	if len(cursor) > 0 {
+		params.Add("after", string(cursor))
+	}
+
+	var data ClipsResponseBody
+	if err := twitch.getJSON(ctx, "clips", params, &data); err != nil {
+		return nil, "", err
+	}
+	return data.Data, data.Pagination.Cursor, nil
+}
+
+// GetUserClips pages through a broadcaster's clips until it has limit of them
+// or runs out, returning what it has so far along with any error
+func (twitch *Service) GetUserClips(ctx context.Context, broadcasterId string, limit int, filter ClipFilter) ([]Clip, error) {
+	if twitch.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, twitch.Timeout)
+		defer cancel()
+	}
+
+	var results []Clip
+	cursor := Cursor("")
+	for {
+		batch, next, err := twitch.GetUserClipsPage(ctx, broadcasterId, limit-len(results), filter, cursor)
+		if err != nil {
+			return results, err
+		}
+		twitch.Log.Debug("Retrieved page of clips", "count", len(batch), "cursor", next)
+
+		results = append(results, batch...)
+		cursor = next
+
+		// Helix can return an empty page with a cursor, which would loop forever
+		if len(results) >= limit || cursor == "" || len(batch) == 0 {
+			// Helix doesn't always keep to first, so the last page can overshoot
+			return results[:min(limit, len(results))], nil
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const helixClipsPayload = `{
+  "data": [
+    {
+      "id": "AwkwardHelplessSalamanderSwiftRage",
+      "url": "https://clips.twitch.tv/AwkwardHelplessSalamanderSwiftRage",
+      "embed_url": "https://clips.twitch.tv/embed?clip=AwkwardHelplessSalamanderSwiftRage",
+      "broadcaster_id": "67955580",
+      "broadcaster_name": "ChewieMelodies",
+      "creator_id": "53834192",
+      "creator_name": "BlackNova03",
+      "video_id": "205586603",
+      "game_id": "488191",
+      "language": "en",
+      "title": "babymetal",
+      "view_count": 10,
+      "created_at": "2017-11-30T22:34:18Z",
+      "thumbnail_url": "https://clips-media-assets.twitch.tv/157589949-preview-480x272.jpg",
+      "duration": 12.9,
+      "vod_offset": 1957,
+      "is_featured": true
+    }
+  ],
+  "pagination": {}
+}`
+
+func TestGetUserClips(t *testing.T) {
+	twitch, c := setup(nil, 200, nil)
+	c.raw = helixClipsPayload
+	filter := ClipFilter{
+		StartedAt: time.Date(2017, 11, 1, 0, 0, 0, 0, time.FixedZone("CET", 3600)),
+		EndedAt:   time.Date(2017, 12, 1, 0, 0, 0, 0, time.UTC),
+	}
+	clips, err := twitch.GetUserClips(context.Background(), "67955580", 20, filter)
+
+	if !(err == nil && len(clips) == 1 && len(c.stack) == 1 &&
+		c.stack[0] == "get-clips-map[broadcaster_id:[67955580] ended_at:[2017-12-01T00:00:00Z] first:[20] started_at:[2017-10-31T23:00:00Z]]") {
+		t.Fatalf(`TestGetUserClips failed - stack: %v | clips: %+v | err: %v`, c.stack, clips, err)
+	}
+	clip := clips[0]
+	if !(clip.CreatorName == "BlackNova03" && clip.VideoID == "205586603" && clip.Views == 10 && clip.Duration == 12.9 &&
+		clip.VodOffset != nil && *clip.VodOffset == 1957 && clip.IsFeatured && clip.CreatedAt.Equal(time.Date(2017, 11, 30, 22, 34, 18, 0, time.UTC))) {
+		t.Errorf(`TestGetUserClips failed - clip: %+v`, clip)
+	}
+}
+
+func TestGetUserClipsPaginates(t *testing.T) {
+	twitch, c := setup(nil, 200, nil)
+	c.raw = `{"data": [{"id": "a"}, {"id": "b"}], "pagination": {"cursor": "next"}}`
+	clips, err := twitch.GetUserClips(context.Background(), "1", 5, ClipFilter{})
+
+	if !(err == nil && len(clips) == 5 && clips[4].ID == "a" && len(c.stack) == 3 &&
+		c.stack[1] == "get-clips-map[after:[next] broadcaster_id:[1] first:[3]]" &&
+		c.stack[2] == "get-clips-map[after:[next] broadcaster_id:[1] first:[1]]") {
+		t.Errorf(`TestGetUserClipsPaginates failed - stack: %v | len(clips): %d | err: %v`, c.stack, len(clips), err)
+	}
+}
+
+func TestGetUserClipsEmptyPage(t *testing.T) {
+	twitch, c := setup(nil, 200, nil)
+	c.raw = `{"data": [], "pagination": {"cursor": "next"}}`
+	clips, err := twitch.GetUserClips(context.Background(), "1", 5, ClipFilter{})
+
+	if !(err == nil && len(clips) == 0 && len(c.stack) == 1) {
+		t.Errorf(`TestGetUserClipsEmptyPage failed - stack: %v | err: %v`, c.stack, err)
+	}
+}
+
+func TestGetUserClipsCancelled(t *testing.T) {
+	twitch, c := setup(nil, 200, nil)
+	c.raw = helixClipsPayload
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	clips, err := twitch.GetUserClips(ctx, "1", 5, ClipFilter{})
+
+	if !(len(clips) == 0 && errors.Is(err, context.Canceled)) {
+		t.Errorf(`TestGetUserClipsCancelled failed - stack: %v | err: %v`, c.stack, err)
+	}
+}
@@ -0,0 +1,83 @@
+package twitch
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+type flightCall struct {
+	done    chan struct{}
+	cancel  context.CancelFunc
+	waiters int
+	videos  []Video
+	err     error
+}
+
+// flightGroup de-duplicates concurrent fetches of the same videos. The first
+// caller starts the fetch and everyone asking for the same key while it is in
+// flight waits for and shares its result. The fetch runs detached from any
+// one caller's context and is only cancelled once every waiter has given up.
+// A nil group runs every fetch on its own.
+type flightGroup struct {
+	lock  sync.Mutex
+	calls map[string]*flightCall
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{calls: map[string]*flightCall{}}
+}
+
+func (g *flightGroup) do(ctx context.Context, key string, fetch func(context.Context) ([]Video, error)) (videos []Video, shared bool, err error) {
+	if g == nil {
+		videos, err = fetch(ctx)
+		return videos, false, err
+	}
+
+	g.lock.Lock()
+	call, shared := g.calls[key]
+	if !shared {
+		// Keep the context's values (such as a cache bypass) but not its cancellation
+		fetchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &flightCall{done: make(chan struct{}), cancel: cancel}
+		g.calls[key] = call
+		go g.run(fetchCtx, key, call, fetch)
+	}
+	call.waiters++
+	g.lock.Unlock()
+
+	select {
+	case <-call.done:
+		return slices.Clone(call.videos), shared, call.err
+	case <-ctx.Done():
+		g.leave(key, call)
+		return nil, shared, ctx.Err()
+	}
+}
+
+func (g *flightGroup) run(ctx context.Context, key string, call *flightCall, fetch func(context.Context) ([]Video, error)) {
+	defer call.cancel()
+	call.videos, call.err = fetch(ctx)
+
+	g.lock.Lock()
+	if g.calls[key] == call {
+		delete(g.calls, key)
+	}
+	g.lock.Unlock()
+	close(call.done)
+}
+
+// leave cancels the fetch once nobody is waiting for it any more, and stops
+// later callers from joining the cancelled fetch
+func (g *flightGroup) leave(key string, call *flightCall) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	call.waiters--
+	if call.waiters == 0 {
+		call.cancel()
+		if g.calls[key] == call {
+			delete(g.calls, key)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package twitch
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Serves paginated videos slowly enough for concurrent callers to overlap,
+// counting every Helix request made
+func slowVideoServer(videos []Video, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(delay):
+		}
+		i, _ := strconv.Atoi(r.URL.Query().Get("after"))
+		body := ResponseBody{Data: videos[i:min(i+100, len(videos))]}
+		if i+100 < len(videos) {
+			body.Pagination.Cursor = Cursor(strconv.Itoa(i + 100))
+		}
+		json.NewEncoder(w).Encode(body)
+	})
+	return httptest.NewServer(handler), &calls
+}
+
+func coalescingService(s *httptest.Server) Service {
+	client := &Client{Log: *slog.Default(), BaseURL: s.URL, bearerToken: "imnotabear"}
+	return Service{Log: *slog.Default(), client: client, inflight: newFlightGroup()}
+}
+
+func TestGetUserVideosCoalesced(t *testing.T) {
+	s, calls := slowVideoServer(generateVideos(250), 50*time.Millisecond)
+	defer s.Close()
+	twitch := coalescingService(s)
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := twitch.GetUserVideos(context.Background(), "test", 250, VideoFilter{})
+			if !(err == nil && len(result) == 250) {
+				t.Errorf(`TestGetUserVideosCoalesced failed - len(results): %d | err: %v`, len(result), err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls.Load() != 3 {
+		t.Errorf(`TestGetUserVideosCoalesced failed - expected 3 Helix calls, got %d`, calls.Load())
+	}
+}
+
+func TestGetUserVideosDifferentQueriesNotCoalesced(t *testing.T) {
+	s, calls := slowVideoServer(generateVideos(50), 50*time.Millisecond)
+	defer s.Close()
+	twitch := coalescingService(s)
+
+	var wg sync.WaitGroup
+	for _, filter := range []VideoFilter{{}, {Type: "archive"}, {Sort: "views"}} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			twitch.GetUserVideos(context.Background(), "test", 50, filter)
+		}()
+	}
+	wg.Wait()
+
+	if calls.Load() != 3 {
+		t.Errorf(`TestGetUserVideosDifferentQueriesNotCoalesced failed - expected 3 Helix calls, got %d`, calls.Load())
+	}
+}
+
+func TestGetUserVideosWaiterCancelled(t *testing.T) {
+	s, calls := slowVideoServer(generateVideos(50), 100*time.Millisecond)
+	defer s.Close()
+	twitch := coalescingService(s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var cancelledErr, sharedErr error
+	var shared []Video
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, cancelledErr = twitch.GetUserVideos(ctx, "test", 50, VideoFilter{})
+	}()
+	go func() {
+		defer wg.Done()
+		time.Sleep(5 * time.Millisecond)
+		shared, sharedErr = twitch.GetUserVideos(context.Background(), "test", 50, VideoFilter{})
+	}()
+	wg.Wait()
+
+	if !(cancelledErr == context.DeadlineExceeded && sharedErr == nil && len(shared) == 50 && calls.Load() == 1) {
+		t.Errorf(`TestGetUserVideosWaiterCancelled failed - errs: %v, %v | len(shared): %d | calls: %d`, cancelledErr, sharedErr, len(shared), calls.Load())
+	}
+}
+
+func TestFlightGroupCancelsAbandonedFetch(t *testing.T) {
+	g := newFlightGroup()
+	fetchErr := make(chan error, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, _, err := g.do(ctx, "key", func(ctx context.Context) ([]Video, error) {
+		<-ctx.Done()
+		fetchErr <- ctx.Err()
+		return nil, ctx.Err()
+	})
+
+	select {
+	case inner := <-fetchErr:
+		if !(err == context.Canceled && inner == context.Canceled) {
+			t.Errorf(`TestFlightGroupCancelsAbandonedFetch failed - errs: %v, %v`, err, inner)
+		}
+	case <-time.After(time.Second):
+		t.Errorf(`TestFlightGroupCancelsAbandonedFetch failed - fetch was never cancelled`)
+	}
+}
+
+func TestFlightGroupKeepsContextValues(t *testing.T) {
+	g := newFlightGroup()
+
+	var bypassed bool
+	g.do(WithoutCache(context.Background()), "key", func(ctx context.Context) ([]Video, error) {
+		bypassed = CacheBypassed(ctx)
+		return nil, nil
+	})
+
+	if !bypassed {
+		t.Errorf(`TestFlightGroupKeepsContextValues failed - cache bypass lost`)
+	}
+}
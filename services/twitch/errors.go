@@ -0,0 +1,118 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// InvalidInputError means twitch rejected the request itself, most often
+// because of a malformed ID or parameter, so retrying it won't help
+type InvalidInputError struct {
+	Err *ApiError
+}
+
+func (e *InvalidInputError) Error() string {
+	return fmt.Sprintf("Twitch API rejected the request - Status Code: %d", e.Err.StatusCode)
+}
+
+func (e *InvalidInputError) Unwrap() error {
+	return e.Err
+}
+
+// RateLimitedError means twitch was still answering 429 after the client had
+// waited out the rate limit, RetryAfter is when the bucket next refills
+type RateLimitedError struct {
+	RetryAfter time.Duration
+	Err        *ApiError
+}
+
+func (e *RateLimitedError) Error() string {
+	return fmt.Sprintf("Rate limited by twitch API - retry after %s", e.RetryAfter)
+}
+
+func (e *RateLimitedError) Unwrap() error {
+	return e.Err
+}
+
+// UpstreamUnavailableError means twitch couldn't be reached, or kept failing
+// with 5xx responses, even after retrying
+type UpstreamUnavailableError struct {
+	Err error
+}
+
+func (e *UpstreamUnavailableError) Error() string {
+	return fmt.Sprintf("Twitch API unavailable: %v", e.Err)
+}
+
+func (e *UpstreamUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout reports whether twitch was too slow rather than failing outright
+func (e *UpstreamUnavailableError) Timeout() bool {
+	var netErr net.Error
+	return errors.Is(e.Err, context.DeadlineExceeded) || (errors.As(e.Err, &netErr) && netErr.Timeout())
+}
+
+// DecodeError means twitch sent a successful response that couldn't be read
+type DecodeError struct {
+	Path string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("Failed to decode twitch API response from %s: %v", e.Path, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// statusError classifies a non-success response from twitch
+func statusError(response *http.Response) error {
+	apiErr := &ApiError{StatusCode: response.StatusCode}
+	switch {
+	case response.StatusCode == http.StatusTooManyRequests:
+		return &RateLimitedError{RetryAfter: retryAfter(response.Header), Err: apiErr}
+	case response.StatusCode == http.StatusUnauthorized, response.StatusCode == http.StatusForbidden:
+		return &UnauthorizedError{StatusCode: response.StatusCode}
+	case response.StatusCode >= 500:
+		return &UpstreamUnavailableError{Err: apiErr}
+	case response.StatusCode >= 400:
+		return &InvalidInputError{Err: apiErr}
+	default:
+		return apiErr
+	}
+}
+
+// transportError classifies an error from sending a request. Cancellations
+// and auth failures already say what went wrong so are left as they are.
+func transportError(err error) error {
+	var oauthErr *OAuthError
+	var unauthorizedErr *UnauthorizedError
+	switch {
+	case errors.Is(err, context.Canceled), errors.As(err, &oauthErr), errors.As(err, &unauthorizedErr):
+		return err
+	default:
+		return &UpstreamUnavailableError{Err: err}
+	}
+}
+
+// retryAfter works out how long until the rate limit bucket refills, from
+// the Helix reset header or a standard Retry-After
+func retryAfter(header http.Header) time.Duration {
+	if reset, err := strconv.ParseInt(header.Get("Ratelimit-Reset"), 10, 64); err == nil {
+		if until := time.Until(time.Unix(reset, 0)); until > 0 {
+			return until
+		}
+	}
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return DefaultRateLimitBackoff
+}
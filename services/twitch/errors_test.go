@@ -0,0 +1,86 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestStatusError(t *testing.T) {
+	var invalid *InvalidInputError
+	var rateLimited *RateLimitedError
+	var unauthorized *UnauthorizedError
+	var unavailable *UpstreamUnavailableError
+
+	tests := []struct {
+		status   int
+		expected func(error) bool
+	}{
+		{400, func(err error) bool { return errors.As(err, &invalid) }},
+		{404, func(err error) bool { return errors.As(err, &invalid) }},
+		{401, func(err error) bool { return errors.As(err, &unauthorized) }},
+		{403, func(err error) bool { return errors.As(err, &unauthorized) }},
+		{429, func(err error) bool { return errors.As(err, &rateLimited) }},
+		{500, func(err error) bool { return errors.As(err, &unavailable) && !unavailable.Timeout() }},
+		{503, func(err error) bool { return errors.As(err, &unavailable) }},
+	}
+
+	for _, test := range tests {
+		if err := statusError(&http.Response{StatusCode: test.status}); !test.expected(err) {
+			t.Errorf(`statusError(%d) returned %T: %v`, test.status, err, err)
+		}
+	}
+}
+
+func TestStatusErrorKeepsApiError(t *testing.T) {
+	err := statusError(&http.Response{StatusCode: 400})
+
+	var apiErr *ApiError
+	if !(errors.As(err, &apiErr) && apiErr.StatusCode == 400) {
+		t.Errorf(`TestStatusErrorKeepsApiError failed - err: %v`, err)
+	}
+}
+
+func TestTransportError(t *testing.T) {
+	var unavailable *UpstreamUnavailableError
+
+	timeout := transportError(context.DeadlineExceeded)
+	refused := transportError(errors.New("connection refused"))
+	cancelled := transportError(context.Canceled)
+	oauth := transportError(&OAuthError{StatusCode: 400})
+
+	if !(errors.As(timeout, &unavailable) && unavailable.Timeout() &&
+		errors.As(refused, &unavailable) && !unavailable.Timeout() &&
+		cancelled == context.Canceled && !errors.As(oauth, &unavailable)) {
+		t.Errorf(`TestTransportError failed - errs: %v, %v, %v, %v`, timeout, refused, cancelled, oauth)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	reset := http.Header{}
+	reset.Set("Ratelimit-Reset", strconv.FormatInt(time.Now().Add(30*time.Second).Unix(), 10))
+	standard := http.Header{}
+	standard.Set("Retry-After", "12")
+
+	fromReset := retryAfter(reset)
+	fromStandard := retryAfter(standard)
+	fallback := retryAfter(http.Header{})
+
+	if !(fromReset > 28*time.Second && fromReset <= 30*time.Second && fromStandard == 12*time.Second && fallback == DefaultRateLimitBackoff) {
+		t.Errorf(`TestRetryAfter failed - %s, %s, %s`, fromReset, fromStandard, fallback)
+	}
+}
+
+func TestGetUserVideosDecodeError(t *testing.T) {
+	twitch, c := setup(nil, 200, nil)
+	c.raw = `{"data": [`
+	_, err := twitch.GetUserVideos(context.Background(), "test", 10, VideoFilter{})
+
+	var decodeErr *DecodeError
+	if !(errors.As(err, &decodeErr) && decodeErr.Path == "videos") {
+		t.Errorf(`TestGetUserVideosDecodeError failed - err: %v`, err)
+	}
+}
@@ -0,0 +1,25 @@
+package twitch
+
+import (
+	"context"
+	"net/url"
+)
+
+// Follower lists need a user token with moderator:read:followers, but the
+// total is returned to any app token so only the total is exposed here
+type FollowersResponseBody struct {
+	Total      int        `json:"total"`
+	Pagination Pagination `json:"pagination"`
+}
+
+func (twitch *Service) GetFollowerCount(ctx context.Context, broadcasterId string) (int, error) {
+	params := make(url.Values)
+	params.Add("broadcaster_id", broadcasterId)
+	params.Add("first", "1")
+
+	var data FollowersResponseBody
+	if err := twitch.getJSON(ctx, "channels/followers", params, &data); err != nil {
+		return 0, err
+	}
+	return data.Total, nil
+}
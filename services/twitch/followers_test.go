@@ -0,0 +1,28 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGetFollowerCount(t *testing.T) {
+	twitch, c := setup(nil, 200, nil)
+	c.raw = `{"total": 8, "data": [], "pagination": {}}`
+	total, err := twitch.GetFollowerCount(context.Background(), "123456")
+
+	if !(err == nil && total == 8 && c.stack[0] == "get-channels/followers-map[broadcaster_id:[123456] first:[1]]") {
+		t.Errorf(`TestGetFollowerCount failed - stack: %v | total: %d | err: %v`, c.stack, total, err)
+	}
+}
+
+func TestGetFollowerCountError(t *testing.T) {
+	twitch, c := setup(nil, 400, nil)
+	c.raw = `{"error": "Bad Request", "status": 400, "message": "Invalid broadcaster_id"}`
+	_, err := twitch.GetFollowerCount(context.Background(), "nope")
+
+	var invalid *InvalidInputError
+	if !errors.As(err, &invalid) {
+		t.Errorf(`TestGetFollowerCountError failed - err: %v`, err)
+	}
+}
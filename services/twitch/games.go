@@ -0,0 +1,73 @@
+package twitch
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Helix accepts at most this many game IDs in one games request
+	MaxGamesPerRequest = 100
+
+	DefaultGameCacheSize = 10000
+	DefaultGameCacheTTL  = 24 * time.Hour
+)
+
+type Game struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	BoxArtURL string `json:"box_art_url"`
+	IGDBID    string `json:"igdb_id"`
+}
+
+// BoxArt fills in the size placeholders twitch leaves in box art URLs
+func (g Game) BoxArt(width int, height int) string {
+	return strings.NewReplacer("{width}", strconv.Itoa(width), "{height}", strconv.Itoa(height)).Replace(g.BoxArtURL)
+}
+
+type GamesResponseBody struct {
+	Data []Game `json:"data"`
+}
+
+// GetGames looks up games by ID, leaving out any twitch doesn't know. Games
+// are only fetched the first time they are asked for, and in as few requests
+// as Helix allows.
+func (twitch *Service) GetGames(ctx context.Context, ids []string) ([]Game, error) {
+	games := []Game{}
+	missing := []string{}
+	for _, id := range ids {
+		if game, ok := twitch.games.get(id); ok {
+			games = append(games, game)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	twitch.Log.Debug("Game cache lookup", "hits", len(games), "misses", len(missing))
+
+	for start := 0; start < len(missing); start += MaxGamesPerRequest {
+		params := make(url.Values)
+		params["id"] = missing[start:min(start+MaxGamesPerRequest, len(missing))]
+
+		var data GamesResponseBody
+		if err := twitch.getJSON(ctx, "games", params, &data); err != nil {
+			return games, err
+		}
+		for _, game := range data.Data {
+			twitch.games.set(game.ID, game)
+		}
+		games = append(games, data.Data...)
+	}
+	return games, nil
+}
+
+// gameCache remembers games by ID, as their names and box art almost never
+// change. It is bounded as every game a tracked channel streams ends up in
+// it, and entries expire so the odd rename is picked up eventually.
+type gameCache = lruCache[Game]
+
+func newGameCache() *gameCache {
+	return newLRUCache[Game](DefaultGameCacheSize, DefaultGameCacheTTL, nil)
+}
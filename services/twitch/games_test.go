@@ -0,0 +1,70 @@
+package twitch
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"testing"
+)
+
+const helixGamesPayload = `{
+  "data": [
+    {
+      "id": "33214",
+      "name": "Fortnite",
+      "box_art_url": "https://static-cdn.jtvnw.net/ttv-boxart/33214-{width}x{height}.jpg",
+      "igdb_id": "1905"
+    }
+  ]
+}`
+
+func gamesSetup(raw string) (Service, *MockClient) {
+	c := &MockClient{status: 200, raw: raw}
+	twitch := Service{
+		Log:    *slog.Default(),
+		client: c,
+		games:  newGameCache(),
+	}
+	return twitch, c
+}
+
+func TestGetGames(t *testing.T) {
+	twitch, c := gamesSetup(helixGamesPayload)
+	games, err := twitch.GetGames(context.Background(), []string{"33214", "0"})
+
+	if !(err == nil && len(games) == 1 && games[0].Name == "Fortnite" && games[0].IGDBID == "1905" &&
+		len(c.stack) == 1 && c.stack[0] == "get-games-map[id:[33214 0]]") {
+		t.Errorf(`TestGetGames failed - stack: %v | games: %+v | err: %v`, c.stack, games, err)
+	}
+}
+
+func TestGetGamesCached(t *testing.T) {
+	twitch, c := gamesSetup(helixGamesPayload)
+	twitch.GetGames(context.Background(), []string{"33214"})
+	games, err := twitch.GetGames(context.Background(), []string{"33214"})
+
+	if !(err == nil && len(games) == 1 && games[0].ID == "33214" && len(c.stack) == 1) {
+		t.Errorf(`TestGetGamesCached failed - stack: %v | games: %+v | err: %v`, c.stack, games, err)
+	}
+}
+
+func TestGameCacheBounded(t *testing.T) {
+	cache := newGameCache()
+	for i := range DefaultGameCacheSize + 10 {
+		cache.set(strconv.Itoa(i), Game{ID: strconv.Itoa(i)})
+	}
+	_, oldest := cache.get("0")
+	newest, ok := cache.get(strconv.Itoa(DefaultGameCacheSize + 9))
+
+	if !(cache.order.Len() == DefaultGameCacheSize && !oldest && ok && newest.ID == strconv.Itoa(DefaultGameCacheSize+9)) {
+		t.Errorf(`TestGameCacheBounded failed - size: %d | oldest: %t | newest: %+v`, cache.order.Len(), oldest, newest)
+	}
+}
+
+func TestGameBoxArt(t *testing.T) {
+	game := Game{BoxArtURL: "https://static-cdn.jtvnw.net/ttv-boxart/33214-{width}x{height}.jpg"}
+
+	if url := game.BoxArt(285, 380); url != "https://static-cdn.jtvnw.net/ttv-boxart/33214-285x380.jpg" {
+		t.Errorf(`TestGameBoxArt failed - url: %s`, url)
+	}
+}
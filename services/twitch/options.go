@@ -0,0 +1,78 @@
+package twitch
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// Timeout for a single HTTP exchange with twitch, covering connecting,
+// sending the request and reading the response body
+const DefaultHTTPTimeout = 10 * time.Second
+
+type ClientOption func(*Client)
+
+// WithHTTPClient replaces the HTTP client used for both the OAuth grant and
+// the Helix API requests
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(twitch *Client) {
+		twitch.HTTP = httpClient
+	}
+}
+
+func WithBaseURL(baseURL string) ClientOption {
+	return func(twitch *Client) {
+		twitch.BaseURL = baseURL
+	}
+}
+
+func WithOAuthURL(oauthURL string) ClientOption {
+	return func(twitch *Client) {
+		twitch.OAuthURL = oauthURL
+	}
+}
+
+func buildEnvOptions(log slog.Logger) []ClientOption {
+	options := []ClientOption{WithHTTPClient(buildHTTPClient(log))}
+
+	if baseURL, exists := os.LookupEnv("TWITCH_API_URL"); exists {
+		options = append(options, WithBaseURL(baseURL))
+	}
+	if oauthURL, exists := os.LookupEnv("TWITCH_OAUTH_URL"); exists {
+		options = append(options, WithOAuthURL(oauthURL))
+	}
+	return options
+}
+
+func buildHTTPClient(log slog.Logger) *http.Client {
+	timeout := DefaultHTTPTimeout
+	if value, exists := os.LookupEnv("TWITCH_HTTP_TIMEOUT"); exists {
+		if parsed, err := time.ParseDuration(value); err == nil && parsed >= 0 {
+			timeout = parsed
+		} else {
+			log.Warn("Ignoring invalid TWITCH_HTTP_TIMEOUT", "value", value)
+		}
+	}
+
+	// The cloned default transport already honours HTTPS_PROXY and friends,
+	// TWITCH_PROXY_URL only needs setting to proxy twitch traffic alone
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if value, exists := os.LookupEnv("TWITCH_PROXY_URL"); exists {
+		if proxyURL, err := url.Parse(value); err == nil && proxyURL.Host != "" {
+			transport.Proxy = http.ProxyURL(proxyURL)
+		} else {
+			log.Warn("Ignoring invalid TWITCH_PROXY_URL", "value", value)
+		}
+	}
+
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+func (twitch *Client) httpClient() *http.Client {
+	if twitch.HTTP == nil {
+		return http.DefaultClient
+	}
+	return twitch.HTTP
+}
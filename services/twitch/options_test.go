@@ -0,0 +1,95 @@
+package twitch
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Stands in for both id.twitch.tv and api.twitch.tv so the whole flow of
+// granting a token and paginating through videos runs offline
+func mockTwitch(t *testing.T, videos []Video) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /oauth2/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if !(r.Form.Get("client_id") == "client-id" && r.Form.Get("client_secret") == "itsasecret" && r.Form.Get("grant_type") == "client_credentials") {
+			t.Errorf(`mockTwitch got unexpected grant - %v`, r.Form)
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		json.NewEncoder(w).Encode(OAuthResponse{Token: "e2e-token", ExpiresIn: 3600})
+	})
+	mux.HandleFunc("GET /helix/videos", func(w http.ResponseWriter, r *http.Request) {
+		if !(r.Header.Get("Client-Id") == "client-id" && r.Header.Get("Authorization") == "Bearer e2e-token") {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		i, _ := strconv.Atoi(r.URL.Query().Get("after"))
+		body := ResponseBody{Data: videos[i:min(i+100, len(videos))]}
+		if i+100 < len(videos) {
+			body.Pagination.Cursor = Cursor(strconv.Itoa(i + 100))
+		}
+		json.NewEncoder(w).Encode(body)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestBuildServiceEndToEnd(t *testing.T) {
+	t.Setenv("TWITCH_CLIENT_ID", "client-id")
+	t.Setenv("TWITCH_CLIENT_SECRET", "itsasecret")
+	s := mockTwitch(t, generateVideos(150))
+	defer s.Close()
+
+	twitch := BuildService(*slog.Default(),
+		WithHTTPClient(s.Client()),
+		WithBaseURL(s.URL+"/helix"),
+		WithOAuthURL(s.URL+"/oauth2/token"))
+
+	result, err := twitch.GetUserVideos(context.Background(), "test", 150, VideoFilter{})
+
+	if !(err == nil && len(result) == 150) {
+		t.Errorf(`TestBuildServiceEndToEnd failed - len(results): %d | err: %v`, len(result), err)
+	}
+}
+
+func TestBuildClientFromEnv(t *testing.T) {
+	t.Setenv("TWITCH_API_URL", "http://localhost:1234/helix")
+	t.Setenv("TWITCH_OAUTH_URL", "http://localhost:1234/oauth2/token")
+	t.Setenv("TWITCH_HTTP_TIMEOUT", "3s")
+	t.Setenv("TWITCH_PROXY_URL", "http://proxy.internal:3128")
+
+	client := BuildClient(*slog.Default())
+
+	req, _ := http.NewRequest("GET", client.BaseURL, nil)
+	proxy, _ := client.HTTP.Transport.(*http.Transport).Proxy(req)
+	if !(client.BaseURL == "http://localhost:1234/helix" &&
+		client.OAuthURL == "http://localhost:1234/oauth2/token" &&
+		client.HTTP.Timeout == 3*time.Second &&
+		proxy.String() == "http://proxy.internal:3128") {
+		t.Errorf(`TestBuildClientFromEnv failed - base: %s | oauth: %s | timeout: %v | proxy: %v`, client.BaseURL, client.OAuthURL, client.HTTP.Timeout, proxy)
+	}
+}
+
+func TestBuildClientOptionsOverrideEnv(t *testing.T) {
+	t.Setenv("TWITCH_API_URL", "http://localhost:1234/helix")
+	httpClient := &http.Client{}
+
+	client := BuildClient(*slog.Default(), WithBaseURL("http://localhost:5678/helix"), WithHTTPClient(httpClient))
+
+	if !(client.BaseURL == "http://localhost:5678/helix" && client.OAuthURL == DefaultOAuthURL && client.HTTP == httpClient) {
+		t.Errorf(`TestBuildClientOptionsOverrideEnv failed - base: %s | oauth: %s`, client.BaseURL, client.OAuthURL)
+	}
+}
+
+func TestBuildClientDefaults(t *testing.T) {
+	client := BuildClient(*slog.Default())
+
+	if !(client.BaseURL == DefaultBaseURL && client.OAuthURL == DefaultOAuthURL && client.HTTP.Timeout == DefaultHTTPTimeout) {
+		t.Errorf(`TestBuildClientDefaults failed - base: %s | oauth: %s | timeout: %v`, client.BaseURL, client.OAuthURL, client.HTTP.Timeout)
+	}
+}
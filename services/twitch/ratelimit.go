@@ -0,0 +1,94 @@
+package twitch
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Number of times a request is replayed after twitch answers 429
+const MaxRateLimitRetries = 3
+
+// How long to back off after a 429 that came without a usable reset header
+const DefaultRateLimitBackoff = time.Second
+
+// rateLimiter is a client side mirror of the Helix token bucket. Twitch
+// reports the bucket size, the points left and when it refills on every
+// response, and the limiter spends a point per request so that once the
+// bucket is empty callers wait for the refill instead of firing requests
+// that are bound to get a 429. The zero value allows every request until
+// the first set of headers has been seen.
+type rateLimiter struct {
+	lock      sync.Mutex
+	known     bool
+	limit     int
+	remaining int
+	reset     time.Time
+	backoff   time.Duration
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	for {
+		delay := l.reserve()
+		if delay <= 0 {
+			return nil
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// reserve spends a point if one is available, otherwise it returns how long
+// to wait before the bucket refills
+func (l *rateLimiter) reserve() time.Duration {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if !l.known {
+		return 0
+	}
+	now := time.Now()
+	if !now.Before(l.reset) {
+		l.remaining = l.limit
+	}
+	if l.remaining > 0 {
+		l.remaining--
+		return 0
+	}
+	return l.reset.Sub(now)
+}
+
+func (l *rateLimiter) update(header http.Header) {
+	limit, errLimit := strconv.Atoi(header.Get("Ratelimit-Limit"))
+	remaining, errRemaining := strconv.Atoi(header.Get("Ratelimit-Remaining"))
+	reset, errReset := strconv.ParseInt(header.Get("Ratelimit-Reset"), 10, 64)
+	if errLimit != nil || errRemaining != nil || errReset != nil {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.known = true
+	l.limit = limit
+	l.remaining = remaining
+	l.reset = time.Unix(reset, 0)
+}
+
+// exhaust empties the bucket after a 429. If the response headers already
+// told us when the bucket refills that reset time is kept.
+func (l *rateLimiter) exhaust() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	l.remaining = 0
+	if !l.known || !time.Now().Before(l.reset) {
+		l.known = true
+		l.limit = max(l.limit, 1)
+		l.reset = time.Now().Add(cmp.Or(l.backoff, DefaultRateLimitBackoff))
+	}
+}
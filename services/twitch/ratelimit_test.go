@@ -0,0 +1,124 @@
+package twitch
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func rateLimitHeaders(limit int, remaining int, reset time.Time) http.Header {
+	h := http.Header{}
+	h.Set("Ratelimit-Limit", strconv.Itoa(limit))
+	h.Set("Ratelimit-Remaining", strconv.Itoa(remaining))
+	h.Set("Ratelimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+	return h
+}
+
+func TestRateLimiterUnknownAllows(t *testing.T) {
+	l := rateLimiter{}
+
+	if delay := l.reserve(); delay != 0 {
+		t.Errorf(`TestRateLimiterUnknownAllows failed - delay: %v`, delay)
+	}
+}
+
+func TestRateLimiterIgnoresMissingHeaders(t *testing.T) {
+	l := rateLimiter{}
+	l.update(http.Header{"Ratelimit-Limit": {"800"}})
+
+	if l.known {
+		t.Errorf(`TestRateLimiterIgnoresMissingHeaders failed - limit: %d`, l.limit)
+	}
+}
+
+func TestRateLimiterSpendsPoints(t *testing.T) {
+	l := rateLimiter{}
+	l.update(rateLimitHeaders(800, 2, time.Now().Add(time.Hour)))
+
+	first, second, third := l.reserve(), l.reserve(), l.reserve()
+
+	if !(first == 0 && second == 0 && third > 59*time.Minute) {
+		t.Errorf(`TestRateLimiterSpendsPoints failed - delays: %v, %v, %v`, first, second, third)
+	}
+}
+
+func TestRateLimiterRefillsAfterReset(t *testing.T) {
+	l := rateLimiter{}
+	l.update(rateLimitHeaders(800, 0, time.Now().Add(-time.Second)))
+
+	if delay := l.reserve(); !(delay == 0 && l.remaining == 799) {
+		t.Errorf(`TestRateLimiterRefillsAfterReset failed - delay: %v | remaining: %d`, delay, l.remaining)
+	}
+}
+
+func TestRateLimiterWaitCancelled(t *testing.T) {
+	l := rateLimiter{}
+	l.update(rateLimitHeaders(800, 0, time.Now().Add(time.Hour)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := l.wait(ctx)
+
+	if err != context.DeadlineExceeded {
+		t.Errorf(`TestRateLimiterWaitCancelled failed - err: %v`, err)
+	}
+}
+
+func TestTwitchClientRetriesAfter429(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			for k, v := range rateLimitHeaders(800, 0, time.Now().Add(time.Second)) {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	s := httptest.NewServer(handler)
+	defer s.Close()
+
+	client := Client{
+		Log:         *slog.Default(),
+		BaseURL:     s.URL,
+		clientId:    "client-id",
+		bearerToken: "imnotabear",
+	}
+
+	res, err := client.makeRequestWithAuth(context.Background(), "GET", "testpath", url.Values{})
+
+	if !(err == nil && res.StatusCode == 200 && calls.Load() == 2) {
+		t.Errorf(`TestTwitchClientRetriesAfter429 failed - err: %v | calls: %d`, err, calls.Load())
+	}
+}
+
+func TestTwitchClientGivesUpAfter429s(t *testing.T) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	s := httptest.NewServer(handler)
+	defer s.Close()
+
+	client := Client{
+		Log:         *slog.Default(),
+		BaseURL:     s.URL,
+		clientId:    "client-id",
+		bearerToken: "imnotabear",
+		limiter:     rateLimiter{backoff: 10 * time.Millisecond},
+	}
+
+	res, err := client.makeRequestWithAuth(context.Background(), "GET", "testpath", url.Values{})
+
+	if !(err == nil && res.StatusCode == 429 && calls.Load() == MaxRateLimitRetries+1) {
+		t.Errorf(`TestTwitchClientGivesUpAfter429s failed - err: %v | calls: %d`, err, calls.Load())
+	}
+}
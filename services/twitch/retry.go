@@ -0,0 +1,143 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryPolicy controls how requests that fail for transient reasons (5xx
+// responses, dropped connections) are retried. A MaxAttempts below 2
+// disables retrying.
+type RetryPolicy struct {
+	MaxAttempts     int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	Jitter          float64
+	RetryableStatus []int
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     3,
+		BaseDelay:       200 * time.Millisecond,
+		MaxDelay:        5 * time.Second,
+		Jitter:          0.2,
+		RetryableStatus: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+func BuildRetryPolicy(log slog.Logger) RetryPolicy {
+	policy := DefaultRetryPolicy()
+
+	if value, exists := os.LookupEnv("TWITCH_RETRY_MAX_ATTEMPTS"); exists {
+		if attempts, err := strconv.Atoi(value); err == nil && attempts > 0 {
+			policy.MaxAttempts = attempts
+		} else {
+			log.Warn("Ignoring invalid TWITCH_RETRY_MAX_ATTEMPTS", "value", value)
+		}
+	}
+	if value, exists := os.LookupEnv("TWITCH_RETRY_BASE_DELAY"); exists {
+		if delay, err := time.ParseDuration(value); err == nil && delay >= 0 {
+			policy.BaseDelay = delay
+		} else {
+			log.Warn("Ignoring invalid TWITCH_RETRY_BASE_DELAY", "value", value)
+		}
+	}
+	if value, exists := os.LookupEnv("TWITCH_RETRY_MAX_DELAY"); exists {
+		if delay, err := time.ParseDuration(value); err == nil && delay >= 0 {
+			policy.MaxDelay = delay
+		} else {
+			log.Warn("Ignoring invalid TWITCH_RETRY_MAX_DELAY", "value", value)
+		}
+	}
+	if value, exists := os.LookupEnv("TWITCH_RETRY_JITTER"); exists {
+		if jitter, err := strconv.ParseFloat(value, 64); err == nil && jitter >= 0 && jitter <= 1 {
+			policy.Jitter = jitter
+		} else {
+			log.Warn("Ignoring invalid TWITCH_RETRY_JITTER", "value", value)
+		}
+	}
+	if value, exists := os.LookupEnv("TWITCH_RETRY_STATUS_CODES"); exists {
+		if codes, err := parseStatusCodes(value); err == nil {
+			policy.RetryableStatus = codes
+		} else {
+			log.Warn("Ignoring invalid TWITCH_RETRY_STATUS_CODES", "value", value)
+		}
+	}
+
+	log.Debug("Initialising retry policy", "policy", policy)
+	return policy
+}
+
+func parseStatusCodes(value string) ([]int, error) {
+	codes := []int{}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		code, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
+
+func (p RetryPolicy) shouldRetry(response *http.Response, err error) bool {
+	if err != nil {
+		return retryableError(err)
+	}
+	return slices.Contains(p.RetryableStatus, response.StatusCode)
+}
+
+// Auth failures and cancellations won't get better by trying again, anything
+// else that went wrong on the wire (resets, refused connections) might
+func retryableError(err error) bool {
+	var oauthErr *OAuthError
+	var unauthorizedErr *UnauthorizedError
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return false
+	case errors.As(err, &oauthErr), errors.As(err, &unauthorizedErr):
+		return false
+	default:
+		return true
+	}
+}
+
+// sleepContext waits for the given duration, returning early with the
+// context's error if it is cancelled first
+func sleepContext(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// delay returns the exponential backoff before the given retry (1 being the
+// first retry), spread by up to +/- Jitter of itself
+func (p RetryPolicy) delay(retry int) time.Duration {
+	delay := p.BaseDelay << (retry - 1)
+	if delay <= 0 || (p.MaxDelay > 0 && delay > p.MaxDelay) {
+		delay = p.MaxDelay
+	}
+	if p.Jitter > 0 {
+		delay = time.Duration(float64(delay) * (1 + p.Jitter*(2*rand.Float64()-1)))
+	}
+	return delay
+}
@@ -0,0 +1,162 @@
+package twitch
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Fails the first n requests with the given status (or by dropping the
+// connection when status is 0) and succeeds afterwards
+func flakyServer(n int32, status int) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) > n {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		if status == 0 {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+			return
+		}
+		w.WriteHeader(status)
+	})
+	return httptest.NewServer(handler), &calls
+}
+
+func retryClient(s *httptest.Server, attempts int) *Client {
+	return &Client{
+		Log:         *slog.Default(),
+		BaseURL:     s.URL,
+		clientId:    "client-id",
+		bearerToken: "imnotabear",
+		Retry: RetryPolicy{
+			MaxAttempts:     attempts,
+			BaseDelay:       time.Millisecond,
+			MaxDelay:        10 * time.Millisecond,
+			Jitter:          0.5,
+			RetryableStatus: []int{502, 503},
+		},
+	}
+}
+
+func TestRetryRecoversFromTransientStatus(t *testing.T) {
+	s, calls := flakyServer(2, http.StatusServiceUnavailable)
+	defer s.Close()
+
+	res, err := retryClient(s, 3).makeRequestWithAuth(context.Background(), "GET", "testpath", url.Values{})
+
+	if !(err == nil && res.StatusCode == 200 && calls.Load() == 3) {
+		t.Errorf(`TestRetryRecoversFromTransientStatus failed - err: %v | calls: %d`, err, calls.Load())
+	}
+}
+
+func TestRetryRecoversFromDroppedConnection(t *testing.T) {
+	s, calls := flakyServer(1, 0)
+	defer s.Close()
+
+	res, err := retryClient(s, 3).makeRequestWithAuth(context.Background(), "GET", "testpath", url.Values{})
+
+	if !(err == nil && res.StatusCode == 200 && calls.Load() == 2) {
+		t.Errorf(`TestRetryRecoversFromDroppedConnection failed - err: %v | calls: %d`, err, calls.Load())
+	}
+}
+
+func TestRetryGivesUpAfterMaxAttempts(t *testing.T) {
+	s, calls := flakyServer(5, http.StatusBadGateway)
+	defer s.Close()
+
+	res, err := retryClient(s, 3).makeRequestWithAuth(context.Background(), "GET", "testpath", url.Values{})
+
+	if !(err == nil && res.StatusCode == 502 && calls.Load() == 3) {
+		t.Errorf(`TestRetryGivesUpAfterMaxAttempts failed - err: %v | calls: %d`, err, calls.Load())
+	}
+}
+
+func TestRetrySkipsNonRetryableStatus(t *testing.T) {
+	s, calls := flakyServer(1, http.StatusBadRequest)
+	defer s.Close()
+
+	res, err := retryClient(s, 3).makeRequestWithAuth(context.Background(), "GET", "testpath", url.Values{})
+
+	if !(err == nil && res.StatusCode == 400 && calls.Load() == 1) {
+		t.Errorf(`TestRetrySkipsNonRetryableStatus failed - err: %v | calls: %d`, err, calls.Load())
+	}
+}
+
+func TestRetryWaitCancelled(t *testing.T) {
+	s, calls := flakyServer(5, http.StatusBadGateway)
+	defer s.Close()
+	client := retryClient(s, 3)
+	client.Retry.BaseDelay = time.Hour
+	client.Retry.MaxDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	res, err := client.makeRequestWithAuth(ctx, "GET", "testpath", url.Values{})
+
+	if !(res == nil && err == context.DeadlineExceeded && calls.Load() == 1) {
+		t.Errorf(`TestRetryWaitCancelled failed - err: %v | calls: %d`, err, calls.Load())
+	}
+}
+
+func TestRetryDelayBackoff(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	delays := []time.Duration{policy.delay(1), policy.delay(2), policy.delay(3), policy.delay(5), policy.delay(100)}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, time.Second, time.Second}
+	if !slices.Equal(delays, expected) {
+		t.Errorf(`TestRetryDelayBackoff failed - delays: %v`, delays)
+	}
+}
+
+func TestRetryDelayJitter(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second, Jitter: 0.2}
+
+	for range 100 {
+		if delay := policy.delay(1); delay < 80*time.Millisecond || delay > 120*time.Millisecond {
+			t.Errorf(`TestRetryDelayJitter failed - delay %v outside of jitter range`, delay)
+		}
+	}
+}
+
+func TestBuildRetryPolicyFromEnv(t *testing.T) {
+	t.Setenv("TWITCH_RETRY_MAX_ATTEMPTS", "5")
+	t.Setenv("TWITCH_RETRY_BASE_DELAY", "50ms")
+	t.Setenv("TWITCH_RETRY_MAX_DELAY", "2s")
+	t.Setenv("TWITCH_RETRY_JITTER", "0.5")
+	t.Setenv("TWITCH_RETRY_STATUS_CODES", "429, 503")
+
+	policy := BuildRetryPolicy(*slog.Default())
+
+	if !(policy.MaxAttempts == 5 &&
+		policy.BaseDelay == 50*time.Millisecond &&
+		policy.MaxDelay == 2*time.Second &&
+		policy.Jitter == 0.5 &&
+		slices.Equal(policy.RetryableStatus, []int{429, 503})) {
+		t.Errorf(`TestBuildRetryPolicyFromEnv failed - policy: %+v`, policy)
+	}
+}
+
+func TestBuildRetryPolicyInvalidEnv(t *testing.T) {
+	t.Setenv("TWITCH_RETRY_MAX_ATTEMPTS", "lots")
+	t.Setenv("TWITCH_RETRY_JITTER", "2")
+	t.Setenv("TWITCH_RETRY_STATUS_CODES", "5xx")
+
+	policy := BuildRetryPolicy(*slog.Default())
+	defaults := DefaultRetryPolicy()
+
+	if !(policy.MaxAttempts == defaults.MaxAttempts &&
+		policy.Jitter == defaults.Jitter &&
+		slices.Equal(policy.RetryableStatus, defaults.RetryableStatus)) {
+		t.Errorf(`TestBuildRetryPolicyInvalidEnv failed - policy: %+v`, policy)
+	}
+}
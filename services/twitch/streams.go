@@ -0,0 +1,51 @@
+package twitch
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// Helix accepts at most this many user IDs in one streams request
+const MaxStreamsPerRequest = 100
+
+type Stream struct {
+	ID           string    `json:"id"`
+	UserID       string    `json:"user_id"`
+	UserLogin    string    `json:"user_login"`
+	UserName     string    `json:"user_name"`
+	GameID       string    `json:"game_id"`
+	GameName     string    `json:"game_name"`
+	Type         string    `json:"type"`
+	Title        string    `json:"title"`
+	Tags         []string  `json:"tags"`
+	ViewerCount  int       `json:"viewer_count"`
+	StartedAt    time.Time `json:"started_at"`
+	Language     string    `json:"language"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	IsMature     bool      `json:"is_mature"`
+}
+
+type StreamsResponseBody struct {
+	Data       []Stream   `json:"data"`
+	Pagination Pagination `json:"pagination"`
+}
+
+// GetStreams returns the live streams of the given users, users that are
+// offline are simply left out. Any number of users can be asked for, they are
+// split into as many requests as Helix needs.
+func (twitch *Service) GetStreams(ctx context.Context, userIds []string) ([]Stream, error) {
+	streams := []Stream{}
+	for start := 0; start < len(userIds); start += MaxStreamsPerRequest {
+		params := make(url.Values)
+		params["user_id"] = userIds[start:min(start+MaxStreamsPerRequest, len(userIds))]
+		params.Add("first", "100")
+
+		var data StreamsResponseBody
+		if err := twitch.getJSON(ctx, "streams", params, &data); err != nil {
+			return streams, err
+		}
+		streams = append(streams, data.Data...)
+	}
+	return streams, nil
+}
@@ -0,0 +1,70 @@
+package twitch
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+const helixStreamsPayload = `{
+  "data": [
+    {
+      "id": "40952121085",
+      "user_id": "101051819",
+      "user_login": "afro",
+      "user_name": "Afro",
+      "game_id": "32982",
+      "game_name": "Grand Theft Auto V",
+      "type": "live",
+      "title": "Jacob: Digital Den Laptops & Routers",
+      "tags": ["English"],
+      "viewer_count": 1490,
+      "started_at": "2021-03-10T03:18:11Z",
+      "language": "en",
+      "thumbnail_url": "https://static-cdn.jtvnw.net/previews-ttv/live_user_afro-{width}x{height}.jpg",
+      "tag_ids": [],
+      "is_mature": false
+    }
+  ],
+  "pagination": {}
+}`
+
+func TestGetStreams(t *testing.T) {
+	twitch, c := setup(nil, 200, nil)
+	c.raw = helixStreamsPayload
+	streams, err := twitch.GetStreams(context.Background(), []string{"101051819", "1234"})
+
+	if !(err == nil && len(streams) == 1 && len(c.stack) == 1 &&
+		c.stack[0] == "get-streams-map[first:[100] user_id:[101051819 1234]]") {
+		t.Fatalf(`TestGetStreams failed - stack: %v | streams: %+v | err: %v`, c.stack, streams, err)
+	}
+	stream := streams[0]
+	if !(stream.ID == "40952121085" && stream.GameName == "Grand Theft Auto V" && stream.ViewerCount == 1490 &&
+		stream.StartedAt.Equal(time.Date(2021, 3, 10, 3, 18, 11, 0, time.UTC))) {
+		t.Errorf(`TestGetStreams failed - stream: %+v`, stream)
+	}
+}
+
+func TestGetStreamsBatches(t *testing.T) {
+	twitch, c := setup(nil, 200, nil)
+	c.raw = `{"data": []}`
+	ids := []string{}
+	for i := range 250 {
+		ids = append(ids, fmt.Sprint(i))
+	}
+	streams, err := twitch.GetStreams(context.Background(), ids)
+
+	if !(err == nil && streams != nil && len(streams) == 0 && len(c.stack) == 3) {
+		t.Errorf(`TestGetStreamsBatches failed - calls: %d | err: %v`, len(c.stack), err)
+	}
+}
+
+func TestGetStreamsNoUsers(t *testing.T) {
+	twitch, c := setup(nil, 200, nil)
+	streams, err := twitch.GetStreams(context.Background(), nil)
+
+	if !(err == nil && len(streams) == 0 && len(c.stack) == 0) {
+		t.Errorf(`TestGetStreamsNoUsers failed - stack: %v | err: %v`, c.stack, err)
+	}
+}
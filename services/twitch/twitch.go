@@ -1,18 +1,79 @@
 package twitch
 
 import (
+	"context"
+	"encoding/json"
+	"io"
 	"log/slog"
+	"net/url"
+	"os"
+	"time"
 )
 
+const DefaultUpstreamTimeout = 30 * time.Second
+
+// VideoObserver is shown every page of videos fetched fresh from twitch,
+// cached pages aren't shown again
+type VideoObserver interface {
+	ObserveVideos([]Video)
+}
+
 type Service struct {
-	Log    slog.Logger
-	client IClient
+	Log      slog.Logger
+	Timeout  time.Duration
+	Observer VideoObserver
+	client   IClient
+	users    *userCache
+	games    *gameCache
+	videos   *pageCache
+	inflight *flightGroup
+}
+
+func BuildService(log slog.Logger, options ...ClientOption) *Service {
+	return &Service{
+		Log:      log,
+		Timeout:  getUpstreamTimeout(log),
+		client:   BuildClient(log, options...),
+		users:    newUserCache(),
+		games:    newGameCache(),
+		videos:   buildPageCache(log),
+		inflight: newFlightGroup(),
+	}
+
 }
 
-func BuildService(log slog.Logger) Service {
-	return Service{
-		Log:    log,
-		client: BuildClient(log),
+// The timeout bounds a whole paginated fetch rather than each page request
+func getUpstreamTimeout(log slog.Logger) time.Duration {
+	value, exists := os.LookupEnv("TWITCH_UPSTREAM_TIMEOUT")
+	if !exists {
+		return DefaultUpstreamTimeout
 	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout < 0 {
+		log.Warn("Ignoring invalid TWITCH_UPSTREAM_TIMEOUT", "value", value)
+		return DefaultUpstreamTimeout
+	}
+	return timeout
+}
+
+// getJSON requests a Helix endpoint and decodes a successful response into
+// data, any failure is returned as one of the typed errors in errors.go
+func (twitch *Service) getJSON(ctx context.Context, path string, params url.Values, data any) error {
+	response, err := twitch.client.get(ctx, path, params)
+	if err != nil {
+		return transportError(err)
+	}
+	defer response.Body.Close()
 
+	if response.StatusCode != 200 {
+		body, _ := io.ReadAll(response.Body)
+		twitch.Log.Debug("Non-success status code recieved", "StatusCode", response.StatusCode, "details", string(body))
+		return statusError(response)
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(data); err != nil {
+		twitch.Log.Error("JSON decoding issue", "err", err)
+		return &DecodeError{Path: path, Err: err}
+	}
+	return nil
 }
@@ -0,0 +1,118 @@
+package twitch
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultUserCacheSize = 10000
+	DefaultUserCacheTTL  = 24 * time.Hour
+)
+
+type UserNotFoundError struct {
+	Channel string
+}
+
+func (e *UserNotFoundError) Error() string {
+	return fmt.Sprintf("No twitch user found for channel %q", e.Channel)
+}
+
+type User struct {
+	ID              string    `json:"id"`
+	Login           string    `json:"login"`
+	DisplayName     string    `json:"display_name"`
+	Type            string    `json:"type"`
+	BroadcasterType string    `json:"broadcaster_type"`
+	Description     string    `json:"description"`
+	ProfileImageURL string    `json:"profile_image_url"`
+	OfflineImageURL string    `json:"offline_image_url"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type UsersResponseBody struct {
+	Data []User `json:"data"`
+}
+
+// GetUsers looks up users by login name and/or ID, Helix accepts up to 100
+// of them combined per request
+func (twitch *Service) GetUsers(ctx context.Context, logins []string, ids []string) ([]User, error) {
+	params := make(url.Values)
+	for _, login := range logins {
+		params.Add("login", login)
+	}
+	for _, id := range ids {
+		params.Add("id", id)
+	}
+
+	var data UsersResponseBody
+	if err := twitch.getJSON(ctx, "users", params, &data); err != nil {
+		return nil, err
+	}
+	return data.Data, nil
+}
+
+// ResolveUserId turns a channel given as either a login name or a user ID into
+// a user ID. Logins can be entirely numeric, so numeric channels are looked up
+// as both and a matching ID wins.
+func (twitch *Service) ResolveUserId(ctx context.Context, channel string) (string, error) {
+	channel = strings.ToLower(channel)
+	if id, ok := twitch.users.get(channel); ok {
+		return id, nil
+	}
+
+	if twitch.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, twitch.Timeout)
+		defer cancel()
+	}
+
+	var ids []string
+	if isNumeric(channel) {
+		ids = []string{channel}
+	}
+	users, err := twitch.GetUsers(ctx, []string{channel}, ids)
+	if err != nil {
+		return "", err
+	}
+
+	var match *User
+	for i, user := range users {
+		if user.ID == channel {
+			match = &users[i]
+			break
+		}
+		if user.Login == channel {
+			match = &users[i]
+		}
+	}
+	if match == nil {
+		return "", &UserNotFoundError{Channel: channel}
+	}
+
+	twitch.Log.Debug("Resolved channel", "channel", channel, "userId", match.ID)
+	twitch.users.set(channel, match.ID)
+	return match.ID, nil
+}
+
+func isNumeric(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+// userCache remembers channel to user ID mappings, which only change when a
+// streamer renames their account. It is bounded as any channel a client asks
+// about that exists ends up in it, and entries expire so renames are picked
+// up eventually.
+type userCache = lruCache[string]
+
+func newUserCache() *userCache {
+	return newLRUCache[string](DefaultUserCacheSize, DefaultUserCacheTTL, nil)
+}
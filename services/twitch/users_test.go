@@ -0,0 +1,131 @@
+package twitch
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const helixUsersPayload = `{
+  "data": [
+    {
+      "id": "141981764",
+      "login": "twitchdev",
+      "display_name": "TwitchDev",
+      "type": "",
+      "broadcaster_type": "partner",
+      "description": "Supporting third-party developers building Twitch integrations from chatbots to game integrations.",
+      "profile_image_url": "https://static-cdn.jtvnw.net/jtv_user_pictures/8a6381c7-d0c0-4576-b179-38bd5ce1d6af-profile_image-300x300.png",
+      "offline_image_url": "https://static-cdn.jtvnw.net/jtv_user_pictures/3f13ab61-ec78-4fe6-8481-8682cb3b0ac2-channel_offline_image-1920x1080.png",
+      "created_at": "2016-12-14T20:32:28Z"
+    }
+  ]
+}`
+
+func usersSetup(raw string) (Service, *MockClient) {
+	c := &MockClient{status: 200, raw: raw}
+	twitch := Service{
+		Log:    *slog.Default(),
+		client: c,
+		users:  newUserCache(),
+	}
+	return twitch, c
+}
+
+func TestGetUsers(t *testing.T) {
+	twitch, c := usersSetup(helixUsersPayload)
+	users, err := twitch.GetUsers(context.Background(), []string{"twitchdev"}, []string{"1234"})
+
+	if !(err == nil && len(users) == 1 && users[0].ID == "141981764" && users[0].BroadcasterType == "partner" &&
+		c.stack[0] == "get-users-map[id:[1234] login:[twitchdev]]") {
+		t.Errorf(`TestGetUsers failed - stack: %v | users: %+v | err: %v`, c.stack, users, err)
+	}
+}
+
+func TestResolveUserIdByLogin(t *testing.T) {
+	twitch, c := usersSetup(helixUsersPayload)
+	first, err1 := twitch.ResolveUserId(context.Background(), "TwitchDev")
+	second, err2 := twitch.ResolveUserId(context.Background(), "twitchdev")
+
+	if !(err1 == nil && err2 == nil && first == "141981764" && second == "141981764" &&
+		len(c.stack) == 1 && c.stack[0] == "get-users-map[login:[twitchdev]]") {
+		t.Errorf(`TestResolveUserIdByLogin failed - stack: %v | ids: %s, %s | errs: %v, %v`, c.stack, first, second, err1, err2)
+	}
+}
+
+func TestResolveUserIdById(t *testing.T) {
+	twitch, c := usersSetup(helixUsersPayload)
+	id, err := twitch.ResolveUserId(context.Background(), "141981764")
+
+	if !(err == nil && id == "141981764" && c.stack[0] == "get-users-map[id:[141981764] login:[141981764]]") {
+		t.Errorf(`TestResolveUserIdById failed - stack: %v | id: %s | err: %v`, c.stack, id, err)
+	}
+}
+
+func TestResolveUserIdPrefersIdMatch(t *testing.T) {
+	twitch, _ := usersSetup(`{"data": [{"id": "999", "login": "12345"}, {"id": "12345", "login": "someone"}]}`)
+	id, err := twitch.ResolveUserId(context.Background(), "12345")
+
+	if !(err == nil && id == "12345") {
+		t.Errorf(`TestResolveUserIdPrefersIdMatch failed - id: %s | err: %v`, id, err)
+	}
+}
+
+func TestResolveUserIdNotFound(t *testing.T) {
+	twitch, c := usersSetup(`{"data": []}`)
+	_, err := twitch.ResolveUserId(context.Background(), "nobody")
+
+	var notFound *UserNotFoundError
+	if !(errors.As(err, &notFound) && notFound.Channel == "nobody" && len(c.stack) == 1) {
+		t.Errorf(`TestResolveUserIdNotFound failed - stack: %v | err: %v`, c.stack, err)
+	}
+}
+
+func TestResolveUserIdError(t *testing.T) {
+	twitch, _ := usersSetup(`{"data": []}`)
+	twitch.client.(*MockClient).status = 400
+	_, err := twitch.ResolveUserId(context.Background(), "twitchdev")
+
+	var apiErr *ApiError
+	if !(errors.As(err, &apiErr) && apiErr.StatusCode == 400) {
+		t.Errorf(`TestResolveUserIdError failed - err: %v`, err)
+	}
+}
+
+type deadlineClient struct {
+	MockClient
+	deadline bool
+}
+
+func (d *deadlineClient) get(ctx context.Context, path string, params url.Values) (*http.Response, error) {
+	_, d.deadline = ctx.Deadline()
+	return d.MockClient.get(ctx, path, params)
+}
+
+func TestResolveUserIdTimeout(t *testing.T) {
+	c := &deadlineClient{MockClient: MockClient{status: 200, raw: helixUsersPayload}}
+	twitch := Service{Log: *slog.Default(), Timeout: time.Minute, client: c, users: newUserCache()}
+	_, err := twitch.ResolveUserId(context.Background(), "twitchdev")
+
+	if !(err == nil && c.deadline) {
+		t.Errorf(`TestResolveUserIdTimeout failed - deadline: %t | err: %v`, c.deadline, err)
+	}
+}
+
+func TestUserCacheBounded(t *testing.T) {
+	cache := newUserCache()
+	for i := range DefaultUserCacheSize + 10 {
+		cache.set(strconv.Itoa(i), strconv.Itoa(i))
+	}
+	_, oldest := cache.get("0")
+	_, newest := cache.get(strconv.Itoa(DefaultUserCacheSize + 9))
+
+	if !(cache.order.Len() == DefaultUserCacheSize && !oldest && newest) {
+		t.Errorf(`TestUserCacheBounded failed - size: %d | oldest: %t | newest: %t`, cache.order.Len(), oldest, newest)
+	}
+}
@@ -1,37 +1,128 @@
 package twitch
 
 import (
+	"context"
 	"encoding/json"
-	"io"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 )
 
 type Cursor string
 
+type InvalidDurationError struct {
+	VideoID  string
+	Duration string
+}
+
+func (e *InvalidDurationError) Error() string {
+	return fmt.Sprintf("Invalid duration %q for video %s", e.Duration, e.VideoID)
+}
+
+type MutedSegment struct {
+	Duration int `json:"duration"`
+	Offset   int `json:"offset"`
+}
+
 type Video struct {
-	Title    string `json:"title"`
-	Views    int    `json:"view_count"`
-	Duration string `json:"duration"`
+	ID            string         `json:"id"`
+	StreamID      string         `json:"stream_id"`
+	UserID        string         `json:"user_id"`
+	UserLogin     string         `json:"user_login"`
+	UserName      string         `json:"user_name"`
+	Title         string         `json:"title"`
+	Description   string         `json:"description"`
+	CreatedAt     time.Time      `json:"created_at"`
+	PublishedAt   time.Time      `json:"published_at"`
+	URL           string         `json:"url"`
+	ThumbnailURL  string         `json:"thumbnail_url"`
+	Viewable      string         `json:"viewable"`
+	Views         int            `json:"view_count"`
+	Language      string         `json:"language"`
+	Type          string         `json:"type"`
+	Duration      time.Duration  `json:"-"`
+	MutedSegments []MutedSegment `json:"muted_segments"`
+}
+
+// Twitch sends durations in the form "3h8m33s", which time.ParseDuration
+// understands, so they are converted once here rather than by every consumer
+func (v *Video) UnmarshalJSON(data []byte) error {
+	type video Video
+	raw := struct {
+		*video
+		Duration string `json:"duration"`
+	}{video: (*video)(v)}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(raw.Duration)
+	if err != nil {
+		return &InvalidDurationError{VideoID: v.ID, Duration: raw.Duration}
+	}
+	v.Duration = duration
+	return nil
+}
+
+func (v Video) MarshalJSON() ([]byte, error) {
+	type video Video
+	return json.Marshal(struct {
+		video
+		Duration string `json:"duration"`
+	}{video(v), v.Duration.String()})
 }
 
 type Pagination struct {
 	Cursor Cursor `json:"cursor"`
 }
 
+var (
+	VideoTypes   = []string{"all", "archive", "highlight", "upload"}
+	VideoPeriods = []string{"all", "day", "month", "week"}
+	VideoSorts   = []string{"time", "trending", "views"}
+)
+
+// VideoFilter narrows down which videos are returned, empty fields are left
+// for twitch to default. Helix only honours language alongside a game ID, so
+// for a user's videos the language is filtered for here instead.
+type VideoFilter struct {
+	Type     string
+	Period   string
+	Sort     string
+	Language string
+}
+
+func (f VideoFilter) apply(params url.Values) {
+	if f.Type != "" {
+		params.Add("type", f.Type)
+	}
+	if f.Period != "" {
+		params.Add("period", f.Period)
+	}
+	if f.Sort != "" {
+		params.Add("sort", f.Sort)
+	}
+}
+
+func (f VideoFilter) matches(video Video) bool {
+	return f.Language == "" || video.Language == f.Language
+}
+
 type ResponseBody struct {
 	Data       []Video    `json:"data"`
 	Pagination Pagination `json:"pagination"`
 }
 
-func (twitch *Service) GetVideos(params url.Values) (*http.Response, error) {
-	return twitch.client.get("videos", params)
+func (twitch *Service) GetVideos(ctx context.Context, params url.Values) (*http.Response, error) {
+	return twitch.client.get(ctx, "videos", params)
 }
 
-func (twitch *Service) GetUserVideosPage(userId string, limit int, cursor Cursor) ([]Video, Cursor, error) {
+func (twitch *Service) GetUserVideosPage(ctx context.Context, userId string, limit int, filter VideoFilter, cursor Cursor) ([]Video, Cursor, error) {
 	params := make(url.Values)
 	params.Add("user_id", userId)
+	filter.apply(params)
 	if limit <= 100 {
 		params.Add("first", strconv.FormatUint(uint64(limit), 10))
 	} else {
@@ -42,28 +133,48 @@ func (twitch *Service) GetUserVideosPage(userId string, limit int, cursor Cursor
 		params.Add("after", string(cursor))
 	}
 
-	response, err := twitch.GetVideos(params)
-	if err != nil {
-		return nil, "", err
-	}
-	defer response.Body.Close()
-
-	if response.StatusCode != 200 {
-		body, _ := io.ReadAll(response.Body)
-		twitch.Log.Debug("Non-success status code recieved", "StatusCode", response.StatusCode, "details", string(body))
-		return nil, "", &ApiError{StatusCode: response.StatusCode}
+	// Encode sorts by key, so the same query always gives the same cache key
+	key := params.Encode()
+	if !CacheBypassed(ctx) {
+		if page, ok := twitch.videos.get(key); ok {
+			twitch.Log.Debug("Video page cache hit", "key", key)
+			return page.videos, page.cursor, nil
+		}
 	}
+	twitch.Log.Debug("Video page cache miss", "key", key, "bypassed", CacheBypassed(ctx))
 
 	var data ResponseBody
-	if err := json.NewDecoder(response.Body).Decode(&data); err != nil {
-		twitch.Log.Error("JSON decoding issue", "err", err)
+	if err := twitch.getJSON(ctx, "videos", params, &data); err != nil {
 		return nil, "", err
 	}
 
+	if twitch.Observer != nil {
+		twitch.Observer.ObserveVideos(data.Data)
+	}
+	twitch.videos.set(key, videoPage{data.Data, data.Pagination.Cursor})
 	return data.Data, data.Pagination.Cursor, nil
 }
 
-func (twitch *Service) GetUserVideos(userId string, limit int) ([]Video, error) {
+// GetUserVideos pages through a user's videos until it has limit of them or
+// runs out. Concurrent calls for the same videos share a single run.
+func (twitch *Service) GetUserVideos(ctx context.Context, userId string, limit int, filter VideoFilter) ([]Video, error) {
+	key := fmt.Sprintf("%s|%d|%+v|%t", userId, limit, filter, CacheBypassed(ctx))
+	videos, shared, err := twitch.inflight.do(ctx, key, func(ctx context.Context) ([]Video, error) {
+		return twitch.paginateUserVideos(ctx, userId, limit, filter)
+	})
+	if shared {
+		twitch.Log.Debug("Shared in-flight video fetch", "key", key, "count", len(videos))
+	}
+	return videos, err
+}
+
+func (twitch *Service) paginateUserVideos(ctx context.Context, userId string, limit int, filter VideoFilter) ([]Video, error) {
+	if twitch.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, twitch.Timeout)
+		defer cancel()
+	}
+
 	var (
 		batch   []Video
 		cursor  Cursor = ""
@@ -72,13 +183,23 @@ func (twitch *Service) GetUserVideos(userId string, limit int) ([]Video, error)
 	)
 
 	for {
-		batch, cursor, err = twitch.GetUserVideosPage(userId, limit-len(results), cursor)
+		// Filtering here can drop any number of a page's videos, so pages are
+		// asked for in full rather than just what is left of the limit
+		first := limit - len(results)
+		if filter.Language != "" {
+			first = 100
+		}
+		batch, cursor, err = twitch.GetUserVideosPage(ctx, userId, first, filter, cursor)
 		if err != nil {
 			return results, err
 		}
 		twitch.Log.Debug("Retrieved page of videos", "count", len(batch), "cursor", cursor)
 
-		results = append(results, batch...)
+		for _, video := range batch {
+			if filter.matches(video) && len(results) < limit {
+				results = append(results, video)
+			}
+		}
 
 		if len(results) >= limit || cursor == "" {
 			return results, nil
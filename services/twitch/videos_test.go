@@ -2,29 +2,48 @@ package twitch
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 )
 
 type MockClient struct {
 	stack  []string
 	status int
 	videos []Video
+	raw    string
 	err    error
+	// failAfter makes every request after that many answer with a 503
+	failAfter int
 }
 
-func (m *MockClient) get(path string, params url.Values) (*http.Response, error) {
+func (m *MockClient) get(ctx context.Context, path string, params url.Values) (*http.Response, error) {
 	m.stack = append(m.stack, fmt.Sprintf("get-%s-%v", path, params))
 
 	if m.err != nil {
 		return nil, m.err
 	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if m.failAfter > 0 && len(m.stack) > m.failAfter {
+		return buildEmptyResponse(http.StatusServiceUnavailable), nil
+	}
+	if m.raw != "" {
+		r := buildEmptyResponse(m.status)
+		r.Body = io.NopCloser(bytes.NewBufferString(m.raw))
+		return r, nil
+	}
 	i := 0
 	cursor := params.Get("after")
 	if len(cursor) > 0 {
@@ -82,14 +101,14 @@ func buildEmptyResponse(status int) *http.Response {
 func generateVideos(n int) []Video {
 	var v []Video
 	for i := range n {
-		v = append(v, Video{Title: fmt.Sprintf("Title %d", i), Views: 100 + i, Duration: "1m1s"})
+		v = append(v, Video{Title: fmt.Sprintf("Title %d", i), Views: 100 + i, Duration: 61 * time.Second})
 	}
 	return v
 }
 
 func TestGetUserVideosError(t *testing.T) {
 	twitch, c := setup(&ApiError{}, 500, nil)
-	result, err := twitch.GetUserVideos("test", 10)
+	result, err := twitch.GetUserVideos(context.Background(), "test", 10, VideoFilter{})
 
 	if !(len(c.stack) == 1 && len(result) == 0 && err != nil) {
 		t.Errorf(`TestGetUserVideosError failed - should have errored`)
@@ -98,16 +117,75 @@ func TestGetUserVideosError(t *testing.T) {
 
 func TestGetUserVideosNonSuccess(t *testing.T) {
 	twitch, c := setup(nil, 400, []Video{})
-	result, err := twitch.GetUserVideos("test", 10)
+	result, err := twitch.GetUserVideos(context.Background(), "test", 10, VideoFilter{})
 
 	if !(len(c.stack) == 1 && len(result) == 0 && err != nil) {
 		t.Errorf(`TestGetUserVideosNonSuccess failed - should have errored`)
 	}
 }
 
+func TestGetUserVideosCancelled(t *testing.T) {
+	twitch, c := setup(nil, 200, generateVideos(150))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := twitch.GetUserVideos(ctx, "test", 150, VideoFilter{})
+
+	if !(len(c.stack) == 1 && len(result) == 0 && errors.Is(err, context.Canceled)) {
+		t.Errorf(`TestGetUserVideosCancelled failed - stack: %v | len(results): %d | err: %v`, c.stack, len(result), err)
+	}
+}
+
+func TestGetUserVideosTimeout(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer api.Close()
+
+	client := &Client{Log: *slog.Default(), BaseURL: api.URL, bearerToken: "imnotabear"}
+	twitch := Service{Log: *slog.Default(), Timeout: 50 * time.Millisecond, client: client}
+
+	start := time.Now()
+	_, err := twitch.GetUserVideos(context.Background(), "test", 10, VideoFilter{})
+
+	if !(errors.Is(err, context.DeadlineExceeded) && time.Since(start) < time.Second) {
+		t.Errorf(`TestGetUserVideosTimeout failed - err: %v | took: %v`, err, time.Since(start))
+	}
+}
+
+func TestGetUserVideosFilter(t *testing.T) {
+	twitch, c := setup(nil, 200, generateVideos(10))
+	filter := VideoFilter{Type: "archive", Period: "week", Sort: "views", Language: "en"}
+	_, err := twitch.GetUserVideos(context.Background(), "test", 10, filter)
+
+	// Helix ignores language for a user's videos, so it is never sent
+	expected := "get-videos-map[first:[100] period:[week] sort:[views] type:[archive] user_id:[test]]"
+	if !(err == nil && len(c.stack) == 1 && c.stack[0] == expected) {
+		t.Errorf(`TestGetUserVideosFilter failed - stack: %v | err: %v`, c.stack, err)
+	}
+}
+
+func TestGetUserVideosLanguage(t *testing.T) {
+	videos := generateVideos(250)
+	for i := range videos {
+		videos[i].Language = "de"
+		if i%10 == 0 {
+			videos[i].Language = "en"
+		}
+	}
+	twitch, c := setup(nil, 200, videos)
+	result, err := twitch.GetUserVideos(context.Background(), "test", 20, VideoFilter{Language: "en"})
+
+	if !(err == nil && len(c.stack) == 2 && len(result) == 20 && result[0].Language == "en" && result[19].Language == "en") {
+		t.Errorf(`TestGetUserVideosLanguage failed - stack: %v | len(results): %d | err: %v`, c.stack, len(result), err)
+	}
+}
+
 func TestGetUserVideosOnePage(t *testing.T) {
 	twitch, c := setup(nil, 200, generateVideos(10))
-	result, err := twitch.GetUserVideos("test", 10)
+	result, err := twitch.GetUserVideos(context.Background(), "test", 10, VideoFilter{})
 
 	if !(len(c.stack) == 1 && len(result) == 10 && err == nil) {
 		t.Errorf(`TestGetUserVideosOnePage failed - stack: %v | len(results): %d | err: %v`, c.stack, len(result), err)
@@ -116,9 +194,156 @@ func TestGetUserVideosOnePage(t *testing.T) {
 
 func TestGetUserVideosTwoPages(t *testing.T) {
 	twitch, c := setup(nil, 200, generateVideos(150))
-	result, err := twitch.GetUserVideos("test", 150)
+	result, err := twitch.GetUserVideos(context.Background(), "test", 150, VideoFilter{})
 
 	if !(len(c.stack) == 2 && len(result) == 150 && err == nil) {
 		t.Errorf(`TestGetUserVideosTwoPages failed - stack: %v | len(results): %d | err: %v`, c.stack, len(result), err)
 	}
 }
+
+// Run with -race: many goroutines paginating through a real client while the
+// first bearer token gets rejected and every later one expires immediately
+func TestGetUserVideosConcurrent(t *testing.T) {
+	m := mockOAuthServer(200, 1, 0)
+	defer m.server.Close()
+
+	videos := generateVideos(250)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") == "Bearer token-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		i, _ := strconv.Atoi(r.URL.Query().Get("after"))
+		body := ResponseBody{Data: videos[i:min(i+100, len(videos))]}
+		if i+100 < len(videos) {
+			body.Pagination.Cursor = Cursor(strconv.Itoa(i + 100))
+		}
+		json.NewEncoder(w).Encode(body)
+	}))
+	defer api.Close()
+
+	client := oauthClient(m)
+	client.BaseURL = api.URL
+	twitch := Service{Log: *slog.Default(), client: client}
+
+	var wg sync.WaitGroup
+	for range 25 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := twitch.GetUserVideos(context.Background(), "test", 250, VideoFilter{})
+			if !(err == nil && len(result) == 250) {
+				t.Errorf(`TestGetUserVideosConcurrent failed - len(results): %d | err: %v`, len(result), err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+const helixVideosPayload = `{
+  "data": [
+    {
+      "id": "335921245",
+      "stream_id": null,
+      "user_id": "141981764",
+      "user_login": "twitchdev",
+      "user_name": "TwitchDev",
+      "title": "Twitch Developers 101",
+      "description": "Welcome to Twitch development!",
+      "created_at": "2018-11-14T21:30:18Z",
+      "published_at": "2018-11-14T22:04:30Z",
+      "url": "https://www.twitch.tv/videos/335921245",
+      "thumbnail_url": "https://static-cdn.jtvnw.net/cf_vods/d2nvs31859zcd8/twitchdev/335921245/ce0f3a7f-57a3-4152-bc06-0c6610189fb3/thumb/index-0000000000-%{width}x%{height}.jpg",
+      "viewable": "public",
+      "view_count": 1863062,
+      "language": "en",
+      "type": "upload",
+      "duration": "3h8m33s",
+      "muted_segments": [
+        {
+          "duration": 30,
+          "offset": 120
+        }
+      ]
+    }
+  ],
+  "pagination": {}
+}`
+
+func TestGetUserVideosPageDecodesFullVideo(t *testing.T) {
+	twitch, c := setup(nil, 200, nil)
+	c.raw = helixVideosPayload
+	result, cursor, err := twitch.GetUserVideosPage(context.Background(), "141981764", 10, VideoFilter{}, "")
+
+	if !(err == nil && cursor == "" && len(result) == 1) {
+		t.Fatalf(`TestGetUserVideosPageDecodesFullVideo failed - len(results): %d | err: %v`, len(result), err)
+	}
+	v := result[0]
+	if !(v.ID == "335921245" && v.StreamID == "" && v.UserID == "141981764" &&
+		v.UserLogin == "twitchdev" && v.UserName == "TwitchDev" &&
+		v.Title == "Twitch Developers 101" && v.Description == "Welcome to Twitch development!" &&
+		v.CreatedAt.Equal(time.Date(2018, 11, 14, 21, 30, 18, 0, time.UTC)) &&
+		v.PublishedAt.Equal(time.Date(2018, 11, 14, 22, 4, 30, 0, time.UTC)) &&
+		v.URL == "https://www.twitch.tv/videos/335921245" && len(v.ThumbnailURL) > 0 &&
+		v.Viewable == "public" && v.Views == 1863062 && v.Language == "en" && v.Type == "upload" &&
+		v.Duration == 3*time.Hour+8*time.Minute+33*time.Second &&
+		len(v.MutedSegments) == 1 && v.MutedSegments[0] == MutedSegment{Duration: 30, Offset: 120}) {
+		t.Errorf(`TestGetUserVideosPageDecodesFullVideo failed - decoded %+v`, v)
+	}
+}
+
+func TestGetUserVideosPageInvalidDuration(t *testing.T) {
+	twitch, c := setup(nil, 200, nil)
+	c.raw = `{"data": [{"id": "1", "duration": "forever"}], "pagination": {}}`
+	result, _, err := twitch.GetUserVideosPage(context.Background(), "test", 10, VideoFilter{}, "")
+
+	var durationErr *InvalidDurationError
+	if !(len(result) == 0 && errors.As(err, &durationErr) && durationErr.VideoID == "1" && durationErr.Duration == "forever") {
+		t.Errorf(`TestGetUserVideosPageInvalidDuration failed - len(results): %d | err: %v`, len(result), err)
+	}
+}
+
+func TestVideoJSONRoundTrip(t *testing.T) {
+	original := Video{ID: "1", Title: "Title", Views: 5, Duration: 3*time.Hour + 8*time.Minute + 33*time.Second}
+	encoded, _ := json.Marshal(original)
+
+	var decoded Video
+	err := json.Unmarshal(encoded, &decoded)
+
+	if !(err == nil && decoded.ID == original.ID && decoded.Duration == original.Duration && bytes.Contains(encoded, []byte(`"duration":"3h8m33s"`))) {
+		t.Errorf(`TestVideoJSONRoundTrip failed - encoded: %s | err: %v`, encoded, err)
+	}
+}
+
+type recordingObserver struct {
+	pages [][]Video
+}
+
+func (o *recordingObserver) ObserveVideos(videos []Video) {
+	o.pages = append(o.pages, videos)
+}
+
+func TestGetUserVideosObserved(t *testing.T) {
+	twitch, _ := setup(nil, 200, generateVideos(150))
+	twitch.videos = newPageCache(10, time.Minute)
+	observer := &recordingObserver{}
+	twitch.Observer = observer
+
+	twitch.GetUserVideos(context.Background(), "test", 150, VideoFilter{})
+	twitch.GetUserVideos(context.Background(), "test", 150, VideoFilter{})
+
+	if !(len(observer.pages) == 2 && len(observer.pages[0]) == 100 && len(observer.pages[1]) == 50) {
+		t.Errorf(`TestGetUserVideosObserved failed - observed %d pages`, len(observer.pages))
+	}
+}
+
+func TestGetUserVideosPartialResults(t *testing.T) {
+	twitch, c := setup(nil, 200, generateVideos(250))
+	c.failAfter = 2
+	result, err := twitch.GetUserVideos(context.Background(), "test", 250, VideoFilter{})
+
+	var unavailable *UpstreamUnavailableError
+	if !(len(result) == 200 && len(c.stack) == 3 && errors.As(err, &unavailable)) {
+		t.Errorf(`TestGetUserVideosPartialResults failed - stack: %v | len(results): %d | err: %v`, c.stack, len(result), err)
+	}
+}